@@ -4,10 +4,119 @@ CREATE SCHEMA IF NOT EXISTS "ibdwh";
 
 -- Table definitions
 
+-- Daily rollup of ibdwh.price_observations
 CREATE TABLE ibdwh.emas (
 	emas_id VARCHAR(10) PRIMARY KEY,  -- Date format: YYYY-MM-DD
 	jual numeric NULL,
 	beli numeric NULL,
 	created_at timestamp NULL,
 	avg_bpkh numeric NULL
-);
\ No newline at end of file
+);
+
+CREATE TABLE ibdwh.price_observations (
+	observation_id BIGSERIAL PRIMARY KEY,
+	setup_id VARCHAR(100) NOT NULL,
+	url TEXT NOT NULL,
+	jual numeric NOT NULL,
+	beli numeric NOT NULL,
+	jual_label VARCHAR(100) NULL,  -- Page label the jual price was matched to
+	beli_label VARCHAR(100) NULL,  -- Page label the beli price was matched to
+	observed_at timestamp NOT NULL,
+	captured_at timestamp NULL     -- Fetch time of the page the prices came from, as kept with its page snapshot
+);
+
+CREATE INDEX price_observations_observed_at_idx ON ibdwh.price_observations (observed_at);
+
+-- Daily OHLC rollup of ibdwh.price_observations, per setup
+CREATE TABLE ibdwh.emas_ohlc (
+	setup_id VARCHAR(100) NOT NULL,
+	ohlc_date date NOT NULL,
+	jual_open numeric NOT NULL,
+	jual_high numeric NOT NULL,
+	jual_low numeric NOT NULL,
+	jual_close numeric NOT NULL,
+	beli_open numeric NOT NULL,
+	beli_high numeric NOT NULL,
+	beli_low numeric NOT NULL,
+	beli_close numeric NOT NULL,
+	spread_avg numeric NOT NULL,
+	observation_count INT NOT NULL,
+	updated_at timestamp NOT NULL,
+	PRIMARY KEY (setup_id, ohlc_date)
+);
+
+-- Price of every product and weight found by a crawl
+CREATE TABLE ibdwh.product_prices (
+	product_price_id BIGSERIAL PRIMARY KEY,
+	observation_id BIGINT NOT NULL REFERENCES ibdwh.price_observations (observation_id) ON DELETE CASCADE,
+	product VARCHAR(100) NOT NULL,
+	weight_grams numeric NOT NULL,
+	jual numeric NOT NULL,
+	beli numeric NULL,             -- Not every product lists a buyback price
+	observed_at timestamp NOT NULL
+);
+
+CREATE INDEX product_prices_product_weight_idx ON ibdwh.product_prices (product, weight_grams, observed_at);
+
+-- Raw page fetched by every crawl attempt
+CREATE TABLE ibdwh.page_snapshots (
+	snapshot_id BIGSERIAL PRIMARY KEY,
+	setup_id VARCHAR(100) NOT NULL,
+	url TEXT NOT NULL,
+	attempt INT NOT NULL,
+	fetch_mode VARCHAR(20) NOT NULL,
+	status_code INT NULL,          -- HTTP status, NULL when no response was received
+	error TEXT NULL,               -- NULL when the attempt succeeded
+	error_class VARCHAR(20) NULL,  -- "network", "timeout", "blocked", "http", "parse", ... NULL when the attempt succeeded
+	proxy TEXT NULL,               -- Proxy without its password, NULL when none was used
+	user_agent TEXT NULL,          -- NULL when the default user agent was sent
+	content BYTEA NOT NULL,        -- gzip compressed HTML
+	captured_at timestamp NOT NULL
+);
+
+CREATE INDEX page_snapshots_captured_at_idx ON ibdwh.page_snapshots (captured_at);
+CREATE INDEX page_snapshots_setup_id_idx ON ibdwh.page_snapshots (setup_id, captured_at);
+
+-- Screenshots and DOM dumps taken when a browser crawl fails
+CREATE TABLE ibdwh.crawl_artifacts (
+	artifact_id BIGSERIAL PRIMARY KEY,
+	setup_id VARCHAR(100) NOT NULL,
+	url TEXT NOT NULL,
+	attempt INT NOT NULL,
+	kind VARCHAR(20) NOT NULL,     -- "screenshot" or "dom"
+	content_type VARCHAR(100) NOT NULL,
+	content BYTEA NOT NULL,
+	error TEXT NOT NULL,           -- Failure the artifact was taken for
+	created_at timestamp NOT NULL
+);
+
+CREATE INDEX crawl_artifacts_setup_id_idx ON ibdwh.crawl_artifacts (setup_id, created_at);
+
+-- Browser cookies and localStorage kept for each setup between crawls
+CREATE TABLE ibdwh.crawl_sessions (
+	setup_id VARCHAR(100) PRIMARY KEY,
+	cookies JSONB NOT NULL,        -- Cookies of the setup's pages, as reported by the browser
+	local_storage JSONB NOT NULL,  -- localStorage items by origin
+	updated_at timestamp NOT NULL
+);
+
+-- Outcome of every crawl attempt, successful or not
+CREATE TABLE ibdwh.crawl_attempts (
+	attempt_id BIGSERIAL PRIMARY KEY,
+	setup_id VARCHAR(100) NOT NULL,
+	url TEXT NOT NULL,
+	attempt INT NOT NULL,
+	fetch_mode VARCHAR(20) NULL,   -- Mode of the last page fetched, NULL when the attempt fetched nothing
+	outcome VARCHAR(20) NOT NULL,  -- "success", "failure" or "skipped" when the host's circuit breaker refused the attempt
+	error_class VARCHAR(20) NULL,  -- "network", "timeout", "blocked", "http", "parse", ... "circuit_open" for skips, NULL when the attempt succeeded
+	error TEXT NULL,               -- NULL when the attempt succeeded
+	jual_raw TEXT NULL,            -- Text the jual price was parsed from, NULL when no price was found
+	beli_raw TEXT NULL,            -- Text the beli price was parsed from, NULL when no price was found
+	candidates_raw TEXT[] NULL,    -- Texts of the prices found when extraction failed, NULL otherwise
+	jual numeric NULL,             -- Extracted jual price, kept even when the sanity check rejected it
+	beli numeric NULL,             -- Extracted beli price, kept even when the sanity check rejected it
+	started_at timestamp NOT NULL,
+	ended_at timestamp NOT NULL
+);
+
+CREATE INDEX crawl_attempts_setup_id_idx ON ibdwh.crawl_attempts (setup_id, started_at);
\ No newline at end of file
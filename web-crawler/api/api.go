@@ -32,6 +32,18 @@ func (api *Api) SetupRoutes(app *fiber.App) *fiber.App {
 	// Emas Routes
 	emas := app.Group("/emas")
 	emas.Get("/", api.GetAllEmas)
+	emas.Get("/observations", api.GetAllPriceObservations)
+	emas.Get("/ohlc", api.GetAllEmasOhlc)
+	emas.Get("/products", api.GetAllProductPrices)
+
+	// Crawl Artifact Routes
+	artifacts := app.Group("/artifacts")
+	artifacts.Get("/", api.GetAllCrawlArtifacts)
+	artifacts.Get("/:id", api.DownloadCrawlArtifact)
+
+	// Circuit Breaker Routes
+	breakers := app.Group("/breakers")
+	breakers.Get("/", api.GetAllCircuitBreakers)
 
 	return app
 }
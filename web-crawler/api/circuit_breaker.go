@@ -0,0 +1,34 @@
+package api
+
+import (
+	"fmt"
+
+	"web-crawler/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+func (api *Api) GetAllCircuitBreakers(c *fiber.Ctx) error {
+	const op = "[api] - Api.GetAllCircuitBreakers"
+
+	params := &service.GetAllCircuitBreakersParams{}
+
+	logger := api.logger.WithFields(logrus.Fields{
+		"[op]":   op,
+		"params": fmt.Sprintf("%+v", params),
+	})
+
+	logger.Info()
+
+	result, err := api.service.GetAllCircuitBreakers(c.Context(), params)
+	if err != nil {
+		logger.WithError(err).Error()
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(result)
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+
+	"web-crawler/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+func (api *Api) GetAllCrawlArtifacts(c *fiber.Ctx) error {
+	const op = "[api] - Api.GetAllCrawlArtifacts"
+
+	// Parse request queries
+	page := c.QueryInt("page", 1)
+	size := c.QueryInt("size", 10)
+
+	params := &service.GetAllCrawlArtifactsParams{
+		Page:    int32(page),
+		Size:    int32(size),
+		SetupId: c.Query("setup_id"),
+	}
+
+	logger := api.logger.WithFields(logrus.Fields{
+		"[op]":   op,
+		"params": fmt.Sprintf("%+v", params),
+	})
+
+	logger.Info()
+
+	result, err := api.service.GetAllCrawlArtifacts(c.Context(), params)
+	if err != nil {
+		logger.WithError(err).Error()
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+// DownloadCrawlArtifact sends the raw screenshot or DOM dump of an artifact
+func (api *Api) DownloadCrawlArtifact(c *fiber.Ctx) error {
+	const op = "[api] - Api.DownloadCrawlArtifact"
+
+	logger := api.logger.WithFields(logrus.Fields{
+		"[op]": op,
+	})
+
+	// Parse request params
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		err = fmt.Errorf("invalid artifact id: %q", c.Params("id"))
+
+		logger.WithError(err).Warn()
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	params := &service.GetCrawlArtifactParams{
+		ID: int64(id),
+	}
+
+	logger = logger.WithField("params", fmt.Sprintf("%+v", params))
+
+	logger.Info()
+
+	result, err := api.service.GetCrawlArtifact(c.Context(), params)
+	if errors.Is(err, service.ErrCrawlArtifactNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err != nil {
+		logger.WithError(err).Error()
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	artifact := result.Artifact
+
+	extension := ".html"
+	if artifact.Kind == service.CrawlArtifactScreenshot {
+		extension = ".jpg"
+	}
+
+	// Attachment guesses a content type from the extension, the stored one wins
+	c.Attachment(fmt.Sprintf("%s_attempt-%d_%s_%d%s", artifact.SetupID, artifact.Attempt, artifact.Kind, artifact.ArtifactID, extension))
+	c.Set(fiber.HeaderContentType, artifact.ContentType)
+
+	return c.Status(fiber.StatusOK).Send(artifact.Content)
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"fmt"
+
+	"web-crawler/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+func (api *Api) GetAllProductPrices(c *fiber.Ctx) error {
+	const op = "[api] - Api.GetAllProductPrices"
+
+	// Parse request queries
+	page := c.QueryInt("page", 1)
+	size := c.QueryInt("size", 10)
+
+	params := &service.GetAllProductPricesParams{
+		Page:    int32(page),
+		Size:    int32(size),
+		Product: c.Query("product"),
+	}
+
+	logger := api.logger.WithFields(logrus.Fields{
+		"[op]": op,
+	})
+
+	if weightGrams := c.Query("weight_grams"); weightGrams != "" {
+		weight, err := decimal.NewFromString(weightGrams)
+		if err != nil {
+			err = fmt.Errorf("invalid weight_grams: %q", weightGrams)
+
+			logger.WithError(err).Warn()
+
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		params.WeightGrams = decimal.NewNullDecimal(weight)
+	}
+
+	logger = logger.WithField("params", fmt.Sprintf("%+v", params))
+
+	logger.Info()
+
+	result, err := api.service.GetAllProductPrices(c.Context(), params)
+	if err != nil {
+		logger.WithError(err).Error()
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(result)
+}
@@ -0,0 +1,120 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Archive types
+const (
+	TypeFilesystem = "filesystem"
+	TypePostgres   = "postgres"
+)
+
+// Snapshot is the raw page fetched by one crawl attempt
+type Snapshot struct {
+	SetupId   string
+	Url       string
+	Attempt   int
+	FetchMode string
+
+	// StatusCode is the HTTP status of the page, 0 when no response was received
+	StatusCode int
+
+	// Error and ErrorClass are empty when the attempt succeeded
+	Error      string
+	ErrorClass string
+
+	// Proxy is the proxy the page was fetched through without its password, empty when none was used
+	Proxy string
+
+	// UserAgent is empty when the fetcher's default user agent was sent
+	UserAgent string
+
+	// Content is the uncompressed HTML, archives store it compressed
+	Content string
+
+	CapturedAt time.Time
+
+	// Source is where a listed snapshot was read from, the file path or the page_snapshots row
+	Source string
+}
+
+// Filter selects the snapshots to list, zero values select everything
+type Filter struct {
+	SetupId string
+
+	// From and To bound the capture time, To is excluded
+	From time.Time
+	To   time.Time
+}
+
+// matches reports whether the snapshot passes the filter
+func (filter Filter) matches(snapshot *Snapshot) bool {
+	if filter.SetupId != "" && snapshot.SetupId != filter.SetupId {
+		return false
+	}
+
+	if !filter.From.IsZero() && snapshot.CapturedAt.Before(filter.From) {
+		return false
+	}
+
+	if !filter.To.IsZero() && !snapshot.CapturedAt.Before(filter.To) {
+		return false
+	}
+
+	return true
+}
+
+// Retention limits how many snapshots an archive keeps, zero values keep everything
+type Retention struct {
+	MaxAge       time.Duration
+	MaxSnapshots int
+}
+
+// Archive stores page snapshots and prunes the ones beyond its retention
+type Archive interface {
+	Save(ctx context.Context, snapshot *Snapshot) error
+
+	// List returns the kept snapshots passing the filter in capture order, for replays
+	List(ctx context.Context, filter Filter) ([]*Snapshot, error)
+}
+
+// compress gzips the page content
+func compress(content string) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	writer := gzip.NewWriter(&buffer)
+
+	_, err := io.WriteString(writer, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress snapshot: %w", err)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress snapshot: %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// decompress gunzips the page content
+func decompress(content []byte) (string, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress snapshot: %w", err)
+	}
+	defer reader.Close()
+
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress snapshot: %w", err)
+	}
+
+	return string(decompressed), nil
+}
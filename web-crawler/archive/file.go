@@ -0,0 +1,114 @@
+package archive
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ListFiles returns the snapshot pages (.html or .html.gz) of a directory tree sorted by name,
+// or the path itself when it is a file. Archive file names start with the capture time, so
+// sorting by name replays snapshots in capture order.
+func ListFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+
+	err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.IsDir() && isPageFile(entry.Name()) {
+			files = append(files, file)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return filepath.Base(files[i]) < filepath.Base(files[j]) })
+
+	return files, nil
+}
+
+// ReadFile loads a snapshot page, decompressing .gz files, along with its metadata file when there is one
+func ReadFile(path string) (*Snapshot, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress snapshot: %w", err)
+		}
+		defer gzipReader.Close()
+
+		reader = gzipReader
+	}
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	snapshot := &Snapshot{
+		Content: string(content),
+		Source:  path,
+	}
+
+	// Snapshots saved by hand have no metadata
+	base := strings.TrimSuffix(strings.TrimSuffix(path, ".gz"), ".html")
+
+	metadataContent, err := os.ReadFile(base + metadataExtension)
+	if os.IsNotExist(err) {
+		return snapshot, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot metadata: %w", err)
+	}
+
+	var metadata snapshotMetadata
+
+	err = json.Unmarshal(metadataContent, &metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot metadata: %w", err)
+	}
+
+	snapshot.SetupId = metadata.SetupId
+	snapshot.Url = metadata.Url
+	snapshot.Attempt = metadata.Attempt
+	snapshot.FetchMode = metadata.FetchMode
+	snapshot.StatusCode = metadata.StatusCode
+	snapshot.Error = metadata.Error
+	snapshot.ErrorClass = metadata.ErrorClass
+	snapshot.Proxy = metadata.Proxy
+	snapshot.UserAgent = metadata.UserAgent
+	snapshot.CapturedAt = metadata.CapturedAt
+
+	return snapshot, nil
+}
+
+func isPageFile(name string) bool {
+	return strings.HasSuffix(name, ".html") || strings.HasSuffix(name, snapshotExtension)
+}
@@ -0,0 +1,206 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// snapshotExtension is the extension of the compressed page of a snapshot
+	snapshotExtension = ".html.gz"
+
+	// metadataExtension is the extension of the JSON file describing a snapshot
+	metadataExtension = ".json"
+)
+
+// unsafeFileChars matches characters not allowed in snapshot file names
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// snapshotMetadata is the content of the JSON file stored next to every page
+type snapshotMetadata struct {
+	SetupId    string    `json:"setup_id"`
+	Url        string    `json:"url"`
+	Attempt    int       `json:"attempt"`
+	FetchMode  string    `json:"fetch_mode"`
+	StatusCode int       `json:"status_code"`
+	Error      string    `json:"error,omitempty"`
+	ErrorClass string    `json:"error_class,omitempty"`
+	Proxy      string    `json:"proxy,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	CapturedAt time.Time `json:"captured_at"`
+}
+
+// Filesystem keeps snapshots in a local directory.
+//
+// Every snapshot is a gzip compressed HTML file plus a JSON metadata file, both
+// named after the capture time so that listing the directory sorts them.
+type Filesystem struct {
+	logger *logrus.Logger
+
+	directory string
+	retention Retention
+}
+
+func NewFilesystem(logger *logrus.Logger, directory string, retention Retention) (*Filesystem, error) {
+	if directory == "" {
+		return nil, fmt.Errorf("archive directory is required")
+	}
+
+	err := os.MkdirAll(directory, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	return &Filesystem{
+		logger: logger,
+
+		directory: directory,
+		retention: retention,
+	}, nil
+}
+
+func (archive *Filesystem) Save(ctx context.Context, snapshot *Snapshot) error {
+	content, err := compress(snapshot.Content)
+	if err != nil {
+		return err
+	}
+
+	metadata, err := json.MarshalIndent(snapshotMetadata{
+		SetupId:    snapshot.SetupId,
+		Url:        snapshot.Url,
+		Attempt:    snapshot.Attempt,
+		FetchMode:  snapshot.FetchMode,
+		StatusCode: snapshot.StatusCode,
+		Error:      snapshot.Error,
+		ErrorClass: snapshot.ErrorClass,
+		Proxy:      snapshot.Proxy,
+		UserAgent:  snapshot.UserAgent,
+		CapturedAt: snapshot.CapturedAt,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot metadata: %w", err)
+	}
+
+	name := fmt.Sprintf("%s_%s_%d_%s",
+		snapshot.CapturedAt.UTC().Format("20060102T150405.000000000Z"),
+		unsafeFileChars.ReplaceAllString(snapshot.SetupId, "-"),
+		snapshot.Attempt,
+		unsafeFileChars.ReplaceAllString(snapshot.FetchMode, "-"),
+	)
+
+	err = os.WriteFile(filepath.Join(archive.directory, name+snapshotExtension), content, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	err = os.WriteFile(filepath.Join(archive.directory, name+metadataExtension), metadata, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot metadata: %w", err)
+	}
+
+	return archive.prune()
+}
+
+func (archive *Filesystem) List(ctx context.Context, filter Filter) ([]*Snapshot, error) {
+	files, err := ListFiles(archive.directory)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []*Snapshot
+	for _, file := range files {
+		err = ctx.Err()
+		if err != nil {
+			return nil, err
+		}
+
+		snapshot, err := ReadFile(file)
+		if err != nil {
+			// A broken file must not hide the rest of the archive
+			archive.logger.WithFields(logrus.Fields{
+				"[op]":     "[archive] - Filesystem.List",
+				"snapshot": file,
+				"error":    err.Error(),
+			}).Warn("Skipped unreadable snapshot")
+
+			continue
+		}
+
+		if filter.matches(snapshot) {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+
+	return snapshots, nil
+}
+
+// prune removes the snapshots older than the max age or beyond the max count, oldest first
+func (archive *Filesystem) prune() error {
+	if archive.retention.MaxAge <= 0 && archive.retention.MaxSnapshots <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(archive.directory)
+	if err != nil {
+		return fmt.Errorf("failed to list archive directory: %w", err)
+	}
+
+	type snapshotFile struct {
+		name       string
+		capturedAt time.Time
+	}
+
+	var snapshots []snapshotFile
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), snapshotExtension)
+		if !ok || entry.IsDir() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		snapshots = append(snapshots, snapshotFile{
+			name:       name,
+			capturedAt: info.ModTime(),
+		})
+	}
+
+	// Newest first
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].name > snapshots[j].name })
+
+	cutoff := time.Now().Add(-archive.retention.MaxAge)
+
+	for i, snapshot := range snapshots {
+		expired := archive.retention.MaxAge > 0 && snapshot.capturedAt.Before(cutoff)
+		overflow := archive.retention.MaxSnapshots > 0 && i >= archive.retention.MaxSnapshots
+		if !expired && !overflow {
+			continue
+		}
+
+		for _, extension := range []string{snapshotExtension, metadataExtension} {
+			err := os.Remove(filepath.Join(archive.directory, snapshot.name+extension))
+			if err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove snapshot: %w", err)
+			}
+		}
+
+		archive.logger.WithFields(logrus.Fields{
+			"[op]":     "[archive] - Filesystem.prune",
+			"snapshot": snapshot.name,
+		}).Debug("Removed snapshot beyond retention")
+	}
+
+	return nil
+}
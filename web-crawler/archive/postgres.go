@@ -0,0 +1,159 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"web-crawler/store"
+	"web-crawler/store/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/sirupsen/logrus"
+)
+
+// Postgres keeps snapshots in the ibdwh.page_snapshots table
+type Postgres struct {
+	logger *logrus.Logger
+
+	store     store.IStore
+	retention Retention
+}
+
+func NewPostgres(logger *logrus.Logger, store store.IStore, retention Retention) *Postgres {
+	return &Postgres{
+		logger: logger,
+
+		store:     store,
+		retention: retention,
+	}
+}
+
+func (archive *Postgres) Save(ctx context.Context, snapshot *Snapshot) error {
+	content, err := compress(snapshot.Content)
+	if err != nil {
+		return err
+	}
+
+	_, err = archive.store.CreatePageSnapshot(ctx, sqlc.CreatePageSnapshotParams{
+		SetupID:   snapshot.SetupId,
+		Url:       snapshot.Url,
+		Attempt:   int32(snapshot.Attempt),
+		FetchMode: snapshot.FetchMode,
+		StatusCode: pgtype.Int4{
+			Int32: int32(snapshot.StatusCode),
+			Valid: snapshot.StatusCode != 0,
+		},
+		Error: pgtype.Text{
+			String: snapshot.Error,
+			Valid:  snapshot.Error != "",
+		},
+		ErrorClass: pgtype.Text{
+			String: snapshot.ErrorClass,
+			Valid:  snapshot.ErrorClass != "",
+		},
+		Proxy: pgtype.Text{
+			String: snapshot.Proxy,
+			Valid:  snapshot.Proxy != "",
+		},
+		UserAgent: pgtype.Text{
+			String: snapshot.UserAgent,
+			Valid:  snapshot.UserAgent != "",
+		},
+		Content: content,
+		CapturedAt: pgtype.Timestamp{
+			Time:  snapshot.CapturedAt,
+			Valid: true,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create page snapshot: %w", err)
+	}
+
+	return archive.prune(ctx)
+}
+
+func (archive *Postgres) List(ctx context.Context, filter Filter) ([]*Snapshot, error) {
+	rows, err := archive.store.ListPageSnapshots(ctx, sqlc.ListPageSnapshotsParams{
+		SetupID: pgtype.Text{
+			String: filter.SetupId,
+			Valid:  filter.SetupId != "",
+		},
+		From: pgtype.Timestamp{
+			Time:  filter.From,
+			Valid: !filter.From.IsZero(),
+		},
+		To: pgtype.Timestamp{
+			Time:  filter.To,
+			Valid: !filter.To.IsZero(),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list page snapshots: %w", err)
+	}
+
+	snapshots := make([]*Snapshot, 0, len(rows))
+	for _, row := range rows {
+		content, err := decompress(row.Content)
+		if err != nil {
+			return nil, fmt.Errorf("page snapshot %d: %w", row.SnapshotID, err)
+		}
+
+		snapshots = append(snapshots, &Snapshot{
+			SetupId:    row.SetupID,
+			Url:        row.Url,
+			Attempt:    int(row.Attempt),
+			FetchMode:  row.FetchMode,
+			StatusCode: int(row.StatusCode.Int32),
+			Error:      row.Error.String,
+			ErrorClass: row.ErrorClass.String,
+			Proxy:      row.Proxy.String,
+			UserAgent:  row.UserAgent.String,
+			Content:    content,
+			CapturedAt: localTime(row.CapturedAt.Time),
+			Source:     fmt.Sprintf("page_snapshots/%d", row.SnapshotID),
+		})
+	}
+
+	return snapshots, nil
+}
+
+// localTime puts back the location of a timestamp column, which keeps the local wall clock of the time it was saved with
+func localTime(stored time.Time) time.Time {
+	return time.Date(stored.Year(), stored.Month(), stored.Day(), stored.Hour(), stored.Minute(), stored.Second(), stored.Nanosecond(), time.Local)
+}
+
+// prune removes the snapshots older than the max age or beyond the max count
+func (archive *Postgres) prune(ctx context.Context) error {
+	var removed int64
+
+	if archive.retention.MaxAge > 0 {
+		count, err := archive.store.DeletePageSnapshotsBefore(ctx, pgtype.Timestamp{
+			Time:  time.Now().Add(-archive.retention.MaxAge),
+			Valid: true,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to remove expired page snapshots: %w", err)
+		}
+
+		removed += count
+	}
+
+	if archive.retention.MaxSnapshots > 0 {
+		count, err := archive.store.DeletePageSnapshotsOverLimit(ctx, int32(archive.retention.MaxSnapshots))
+		if err != nil {
+			return fmt.Errorf("failed to remove page snapshots over limit: %w", err)
+		}
+
+		removed += count
+	}
+
+	if removed > 0 {
+		archive.logger.WithFields(logrus.Fields{
+			"[op]":    "[archive] - Postgres.prune",
+			"removed": removed,
+		}).Debug("Removed snapshots beyond retention")
+	}
+
+	return nil
+}
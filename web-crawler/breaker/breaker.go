@@ -0,0 +1,310 @@
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultFailureThreshold is how many crawl attempts in a row may fail on a host before its breaker opens when the options leave it unset
+	DefaultFailureThreshold = 5
+
+	// DefaultCooldown is how long an open breaker refuses crawls before letting a probe through when the options leave it unset
+	DefaultCooldown = 30 * time.Minute
+)
+
+// State is the state of a host's breaker
+type State string
+
+// Breaker states
+const (
+	// StateClosed lets every crawl through
+	StateClosed State = "closed"
+	// StateOpen refuses every crawl until the cooldown has passed
+	StateOpen State = "open"
+	// StateHalfOpen lets a single probe through, its outcome closes or opens the breaker again
+	StateHalfOpen State = "half_open"
+)
+
+// ErrOpen is returned for crawls refused because the breaker of their host is open
+var ErrOpen = errors.New("circuit breaker open")
+
+// Options configures the breakers, zero values fall back to the defaults
+type Options struct {
+	FailureThreshold int
+	Cooldown         time.Duration
+}
+
+// circuit is the breaker of one host
+type circuit struct {
+	state State
+
+	failures  int
+	lastError string
+
+	openedAt time.Time
+	retryAt  time.Time
+
+	// probing is set while the half-open probe is running
+	probing bool
+
+	skipped       int64
+	lastSkippedAt time.Time
+}
+
+// Status is the state of a host's breaker as shown by the API
+type Status struct {
+	Host                string     `json:"host"`
+	State               State      `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+	RetryAt             *time.Time `json:"retry_at,omitempty"`
+	Skipped             int64      `json:"skipped"`
+	LastSkippedAt       *time.Time `json:"last_skipped_at,omitempty"`
+}
+
+// Breakers keeps a circuit breaker per target host so that a site which is down is left alone.
+//
+// A breaker opens after FailureThreshold crawl attempts in a row failed on its
+// host and refuses every crawl for Cooldown. It then lets a single probe through:
+// a success closes it, a failure opens it for another cooldown. A nil *Breakers
+// lets everything through.
+type Breakers struct {
+	logger *logrus.Logger
+
+	options Options
+
+	mutex    sync.Mutex
+	circuits map[string]*circuit
+}
+
+func New(logger *logrus.Logger, options Options) *Breakers {
+	if options.FailureThreshold <= 0 {
+		options.FailureThreshold = DefaultFailureThreshold
+	}
+
+	if options.Cooldown <= 0 {
+		options.Cooldown = DefaultCooldown
+	}
+
+	return &Breakers{
+		logger: logger,
+
+		options: options,
+
+		circuits: map[string]*circuit{},
+	}
+}
+
+// Allow reports whether a crawl of the URL may go out, refusals fail with ErrOpen and are counted as skips.
+// Every allowed crawl must be followed by Success, Failure or Release
+func (breakers *Breakers) Allow(rawUrl string) error {
+	const op = "[breaker] Breakers.Allow"
+
+	if breakers == nil {
+		return nil
+	}
+
+	host := hostOf(rawUrl)
+	now := time.Now()
+
+	breakers.mutex.Lock()
+	defer breakers.mutex.Unlock()
+
+	circuit := breakers.circuit(host)
+
+	if circuit.state == StateOpen && !now.Before(circuit.retryAt) {
+		circuit.state = StateHalfOpen
+
+		breakers.logger.WithFields(logrus.Fields{
+			"[op]":    op,
+			"host":    host,
+			"message": "cooldown over, letting a probe through",
+		}).Info()
+	}
+
+	switch {
+	case circuit.state == StateClosed:
+		return nil
+	case circuit.state == StateHalfOpen && !circuit.probing:
+		circuit.probing = true
+
+		return nil
+	}
+
+	circuit.skipped++
+	circuit.lastSkippedAt = now
+
+	if circuit.state == StateHalfOpen {
+		return fmt.Errorf("%w: %s is being probed", ErrOpen, host)
+	}
+
+	return fmt.Errorf("%w: %s until %s", ErrOpen, host, circuit.retryAt.Format(time.RFC3339))
+}
+
+// Success records a crawl the host answered, closing its breaker
+func (breakers *Breakers) Success(rawUrl string) {
+	const op = "[breaker] Breakers.Success"
+
+	if breakers == nil {
+		return
+	}
+
+	host := hostOf(rawUrl)
+
+	breakers.mutex.Lock()
+	defer breakers.mutex.Unlock()
+
+	circuit := breakers.circuit(host)
+
+	if circuit.state != StateClosed {
+		breakers.logger.WithFields(logrus.Fields{
+			"[op]":    op,
+			"host":    host,
+			"message": "probe succeeded, closing circuit breaker",
+		}).Info()
+	}
+
+	circuit.state = StateClosed
+	circuit.failures = 0
+	circuit.probing = false
+}
+
+// Failure records a crawl that failed because of the host, opening its breaker after too many in a row
+func (breakers *Breakers) Failure(rawUrl string, err error) {
+	const op = "[breaker] Breakers.Failure"
+
+	if breakers == nil {
+		return
+	}
+
+	host := hostOf(rawUrl)
+	now := time.Now()
+
+	breakers.mutex.Lock()
+	defer breakers.mutex.Unlock()
+
+	circuit := breakers.circuit(host)
+
+	circuit.failures++
+	circuit.probing = false
+	if err != nil {
+		circuit.lastError = err.Error()
+	}
+
+	// A failed probe opens the breaker right away
+	if circuit.state == StateClosed && circuit.failures < breakers.options.FailureThreshold {
+		return
+	}
+
+	circuit.state = StateOpen
+	circuit.openedAt = now
+	circuit.retryAt = now.Add(breakers.options.Cooldown)
+
+	breakers.logger.WithFields(logrus.Fields{
+		"[op]":                 op,
+		"host":                 host,
+		"consecutive_failures": circuit.failures,
+		"cooldown_seconds":     breakers.options.Cooldown.Seconds(),
+		"last_error":           circuit.lastError,
+		"message":              "circuit breaker opened, crawls of the host are skipped",
+	}).Warn()
+}
+
+// Release ends an allowed crawl that says nothing about the host, such as one stopped before any request
+func (breakers *Breakers) Release(rawUrl string) {
+	if breakers == nil {
+		return
+	}
+
+	host := hostOf(rawUrl)
+
+	breakers.mutex.Lock()
+	defer breakers.mutex.Unlock()
+
+	breakers.circuit(host).probing = false
+}
+
+// Statuses returns the state of every host's breaker, sorted by host
+func (breakers *Breakers) Statuses() []Status {
+	statuses := []Status{}
+
+	if breakers == nil {
+		return statuses
+	}
+
+	now := time.Now()
+
+	breakers.mutex.Lock()
+	defer breakers.mutex.Unlock()
+
+	for host, circuit := range breakers.circuits {
+		status := Status{
+			Host:                host,
+			State:               circuit.state,
+			ConsecutiveFailures: circuit.failures,
+			LastError:           circuit.lastError,
+			Skipped:             circuit.skipped,
+		}
+
+		// An open breaker past its cooldown lets the next crawl through as a probe
+		if status.State == StateOpen && !now.Before(circuit.retryAt) {
+			status.State = StateHalfOpen
+		}
+
+		if circuit.state != StateClosed {
+			status.OpenedAt = timePointer(circuit.openedAt)
+			status.RetryAt = timePointer(circuit.retryAt)
+		}
+
+		if !circuit.lastSkippedAt.IsZero() {
+			status.LastSkippedAt = timePointer(circuit.lastSkippedAt)
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	slices.SortFunc(statuses, func(a, b Status) int {
+		return strings.Compare(a.Host, b.Host)
+	})
+
+	return statuses
+}
+
+// circuit returns the breaker of the host, the caller must hold the mutex
+func (breakers *Breakers) circuit(host string) *circuit {
+	existing := breakers.circuits[host]
+	if existing != nil {
+		return existing
+	}
+
+	created := &circuit{
+		state: StateClosed,
+	}
+
+	breakers.circuits[host] = created
+
+	return created
+}
+
+// hostOf returns the lowercased host of the URL, or the URL itself when it cannot be parsed
+func hostOf(rawUrl string) string {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil || parsed.Host == "" {
+		return strings.ToLower(rawUrl)
+	}
+
+	return strings.ToLower(parsed.Host)
+}
+
+func timePointer(t time.Time) *time.Time {
+	return &t
+}
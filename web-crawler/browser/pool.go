@@ -0,0 +1,543 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	cdpbrowser "github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/chromedp"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultBrowsers is how many browsers are kept running when the options leave it unset
+	DefaultBrowsers = 1
+
+	// DefaultMaxTabs is how many tabs may be open at once when the options leave it unset
+	DefaultMaxTabs = 2
+
+	// DefaultHealthCheckInterval is how often running browsers are checked when the options leave it unset
+	DefaultHealthCheckInterval = 30 * time.Second
+
+	// DefaultStartTimeout bounds a browser start or remote connection when the options leave it unset
+	DefaultStartTimeout = 30 * time.Second
+
+	// healthCheckTimeout bounds how long a browser may take to answer a health check
+	healthCheckTimeout = 10 * time.Second
+)
+
+// ErrRemoteUnreachable is returned when the remote browser could not be connected to within StartTimeout
+var ErrRemoteUnreachable = errors.New("remote browser unreachable")
+
+// Options configures a browser pool, zero values fall back to the defaults
+type Options struct {
+	// Browsers is how many browser processes are kept warm
+	Browsers int
+
+	// MaxTabs caps the tabs open at the same time across all browsers
+	MaxTabs int
+
+	// MaxUses recycles a browser once it has handed out that many tabs, 0 never recycles
+	MaxUses int
+
+	// RemoteUrl connects to an already running Chrome instead of launching one.
+	// It is either the DevTools WebSocket URL (ws://host:9222/devtools/browser/<id>)
+	// or the debugging address (ws://host:9222), the latter is resolved again on
+	// every reconnect so a restarted browser is found.
+	RemoteUrl string
+
+	// HealthCheckInterval is how often running browsers are asked for their version,
+	// a browser that does not answer is dropped and reconnected
+	HealthCheckInterval time.Duration
+
+	// StartTimeout bounds how long a browser may take to start or a remote browser to accept the connection
+	StartTimeout time.Duration
+}
+
+// instance is one running browser of the pool
+type instance struct {
+	id int
+
+	// ready is closed once the browser started or failed to, err tells which.
+	// ctx and cancel are only set once it started.
+	ready chan struct{}
+	err   error
+
+	// ctx is the browser context, it is done once the browser exits or crashes
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	uses   int
+	active int
+
+	// retired browsers hand out no more tabs and are closed once their last tab is released
+	retired bool
+}
+
+// Pool keeps long-lived browsers running and hands out tabs from them.
+//
+// Tabs are opened on the least busy browser. A browser is replaced once it
+// crashed, failed a health check or handed out MaxUses tabs, the replacement is
+// started when the next tab is requested. Browsers start in the background
+// within StartTimeout, the pool stays usable while they do. Remote browsers are connected to
+// instead of started, closing them only closes the pool's tabs and connection.
+type Pool struct {
+	logger *logrus.Logger
+
+	options Options
+
+	// tabs holds a token for every open tab
+	tabs chan struct{}
+
+	mutex    sync.Mutex
+	browsers []*instance
+	launched int
+	closed   bool
+
+	// done stops the health checks once the pool is closed
+	done chan struct{}
+}
+
+func NewPool(logger *logrus.Logger, options Options) *Pool {
+	if options.Browsers <= 0 {
+		options.Browsers = DefaultBrowsers
+	}
+
+	if options.MaxTabs <= 0 {
+		options.MaxTabs = DefaultMaxTabs
+	}
+
+	if options.HealthCheckInterval <= 0 {
+		options.HealthCheckInterval = DefaultHealthCheckInterval
+	}
+
+	if options.StartTimeout <= 0 {
+		options.StartTimeout = DefaultStartTimeout
+	}
+
+	pool := &Pool{
+		logger: logger,
+
+		options: options,
+
+		tabs: make(chan struct{}, options.MaxTabs),
+
+		browsers: make([]*instance, options.Browsers),
+
+		done: make(chan struct{}),
+	}
+
+	go pool.monitor()
+
+	return pool
+}
+
+// Warm starts every browser of the pool that is not running yet and waits for them
+func (pool *Pool) Warm() error {
+	pool.mutex.Lock()
+
+	if pool.closed {
+		pool.mutex.Unlock()
+
+		return fmt.Errorf("browser pool is closed")
+	}
+
+	browsers := make([]*instance, len(pool.browsers))
+	for slot, browser := range pool.browsers {
+		if browser == nil {
+			browser = pool.start(slot)
+		}
+
+		browsers[slot] = browser
+	}
+
+	pool.mutex.Unlock()
+
+	var errs []error
+	for _, browser := range browsers {
+		<-browser.ready
+
+		if browser.err != nil {
+			errs = append(errs, browser.err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Tab opens a new tab, waiting while MaxTabs tabs are already open.
+//
+// ctx only bounds the wait for a free tab. The returned context drives the tab,
+// it is not cancelled along with ctx so that the tab can still be inspected once
+// the caller ran out of time. The release function closes the tab and must be
+// called once the tab is no longer used. Options such as
+// chromedp.WithNewBrowserContext apply to the new tab.
+func (pool *Pool) Tab(ctx context.Context, options ...chromedp.ContextOption) (context.Context, context.CancelFunc, error) {
+	const op = "[browser] Pool.Tab"
+
+	startTime := time.Now()
+
+	select {
+	case pool.tabs <- struct{}{}:
+	case <-ctx.Done():
+		return nil, nil, fmt.Errorf("failed to wait for a free browser tab: %w", ctx.Err())
+	}
+
+	if wait := time.Since(startTime); wait > time.Second {
+		pool.logger.WithFields(logrus.Fields{
+			"[op]":         op,
+			"wait_seconds": wait.Seconds(),
+			"max_tabs":     pool.options.MaxTabs,
+			"message":      "waited for a free browser tab",
+		}).Info()
+	}
+
+	browser, err := pool.acquire()
+	if err != nil {
+		<-pool.tabs
+
+		return nil, nil, err
+	}
+
+	// The tab does not outlive the browser
+	tabCtx, tabCancel := chromedp.NewContext(browser.ctx, options...)
+
+	var once sync.Once
+
+	release := func() {
+		once.Do(func() {
+			// Cancelling the tab context closes the tab, the browser keeps running
+			tabCancel()
+
+			pool.release(browser)
+
+			<-pool.tabs
+		})
+	}
+
+	return tabCtx, release, nil
+}
+
+// Close shuts every browser down, tabs still open are closed with them
+func (pool *Pool) Close() {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if pool.closed {
+		return
+	}
+
+	pool.closed = true
+	close(pool.done)
+
+	// Browsers still starting are shut down as soon as they are up
+	for slot, browser := range pool.browsers {
+		if browser != nil && browser.started() {
+			browser.cancel()
+		}
+
+		pool.browsers[slot] = nil
+	}
+}
+
+// acquire picks the least busy browser and books a tab on it, waiting for the browser to start
+func (pool *Pool) acquire() (*instance, error) {
+	pool.mutex.Lock()
+
+	if pool.closed {
+		pool.mutex.Unlock()
+
+		return nil, fmt.Errorf("browser pool is closed")
+	}
+
+	// Idle browsers are preferred, then empty slots, then the browser with the fewest tabs
+	best := -1
+	for slot, browser := range pool.browsers {
+		if browser != nil && browser.started() && browser.ctx.Err() != nil {
+			pool.logger.WithFields(logrus.Fields{
+				"[op]":       "[browser] Pool.acquire",
+				"browser_id": browser.id,
+				"uses":       browser.uses,
+				"message":    "browser crashed, it is replaced",
+			}).Warn()
+
+			browser.cancel()
+			pool.browsers[slot] = nil
+			browser = nil
+		}
+
+		if best < 0 || busyness(browser) < busyness(pool.browsers[best]) {
+			best = slot
+		}
+	}
+
+	browser := pool.browsers[best]
+	if browser == nil {
+		browser = pool.start(best)
+	}
+
+	browser.uses++
+	browser.active++
+
+	if pool.options.MaxUses > 0 && browser.uses >= pool.options.MaxUses {
+		// The slot is freed for a fresh browser, this one is closed after its last tab
+		browser.retired = true
+		pool.browsers[best] = nil
+	}
+
+	pool.mutex.Unlock()
+
+	// Other tabs are handed out while the browser starts
+	<-browser.ready
+
+	if browser.err != nil {
+		return nil, browser.err
+	}
+
+	return browser, nil
+}
+
+// release gives back a tab booked on the browser
+func (pool *Pool) release(browser *instance) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	browser.active--
+
+	if browser.retired && browser.active == 0 {
+		pool.logger.WithFields(logrus.Fields{
+			"[op]":       "[browser] Pool.release",
+			"browser_id": browser.id,
+			"uses":       browser.uses,
+			"message":    "browser recycled",
+		}).Info()
+
+		browser.cancel()
+	}
+}
+
+// start fills an empty slot with a browser that is started in the background, waiters block on its ready channel
+// The pool mutex must be held
+func (pool *Pool) start(slot int) *instance {
+	pool.launched++
+
+	browser := &instance{
+		id:    pool.launched,
+		ready: make(chan struct{}),
+	}
+
+	pool.browsers[slot] = browser
+
+	go pool.launch(slot, browser)
+
+	return browser
+}
+
+// launch starts the browser without holding the pool mutex and swaps it in once it is up
+func (pool *Pool) launch(slot int, browser *instance) {
+	const op = "[browser] Pool.launch"
+
+	startTime := time.Now()
+
+	ctx, cancel, err := pool.connect()
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	defer close(browser.ready)
+
+	if err == nil && pool.closed {
+		cancel()
+
+		err = fmt.Errorf("browser pool is closed")
+	}
+
+	if err != nil {
+		browser.err = err
+
+		// The slot is left empty so that the next tab or health check tries again
+		if pool.browsers[slot] == browser {
+			pool.browsers[slot] = nil
+		}
+
+		return
+	}
+
+	browser.ctx = ctx
+	browser.cancel = cancel
+
+	pool.logger.WithFields(logrus.Fields{
+		"[op]":                     op,
+		"browser_id":               browser.id,
+		"remote":                   pool.options.RemoteUrl != "",
+		"startup_duration_seconds": time.Since(startTime).Seconds(),
+		"message":                  "browser started",
+	}).Info()
+}
+
+// connect starts a browser, or connects to the remote one, within StartTimeout
+func (pool *Pool) connect() (context.Context, context.CancelFunc, error) {
+	var allocCtx context.Context
+	var allocCancel context.CancelFunc
+	if pool.options.RemoteUrl != "" {
+		allocCtx, allocCancel = chromedp.NewRemoteAllocator(context.Background(), pool.options.RemoteUrl)
+	} else {
+		allocCtx, allocCancel = chromedp.NewExecAllocator(context.Background(), chromedp.DefaultExecAllocatorOptions[:]...)
+	}
+
+	browserCtx, browserCancel := chromedp.NewContext(allocCtx, chromedp.WithLogf(pool.logger.Printf))
+
+	cancel := func() {
+		browserCancel()
+		allocCancel()
+	}
+
+	// Running no action starts or connects to the browser with its first blank tab.
+	// The browser lives as long as its context, so the start is bounded by a timer instead of a deadline on it
+	started := make(chan error, 1)
+	go func() {
+		started <- chromedp.Run(browserCtx)
+	}()
+
+	timer := time.NewTimer(pool.options.StartTimeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case err = <-started:
+	case <-timer.C:
+		cancel()
+		<-started
+
+		err = fmt.Errorf("no answer within %s", pool.options.StartTimeout)
+	}
+
+	if err != nil {
+		cancel()
+
+		// A remote browser is reached over the network, its failures read as network failures rather than timeouts
+		if pool.options.RemoteUrl != "" {
+			return nil, nil, fmt.Errorf("failed to connect to remote browser: %w: %w", ErrRemoteUnreachable, err)
+		}
+
+		return nil, nil, fmt.Errorf("failed to start browser: %w", err)
+	}
+
+	return browserCtx, cancel, nil
+}
+
+// monitor checks the running browsers every HealthCheckInterval until the pool is closed
+func (pool *Pool) monitor() {
+	ticker := time.NewTicker(pool.options.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-pool.done:
+			return
+		case <-ticker.C:
+			pool.healthCheck()
+		}
+	}
+}
+
+// healthCheck replaces every browser that crashed or does not answer and starts the missing ones
+func (pool *Pool) healthCheck() {
+	const op = "[browser] Pool.healthCheck"
+
+	pool.mutex.Lock()
+	browsers := append([]*instance{}, pool.browsers...)
+	pool.mutex.Unlock()
+
+	for slot, browser := range browsers {
+		if browser != nil {
+			// Browsers still starting are left to their start, failed ones are already out of the pool
+			if !browser.started() {
+				continue
+			}
+
+			err := ping(browser)
+			if err == nil {
+				continue
+			}
+
+			pool.logger.WithFields(logrus.Fields{
+				"[op]":       op,
+				"browser_id": browser.id,
+				"error":      err.Error(),
+				"message":    "browser failed health check, reconnecting",
+			}).Warn()
+		}
+
+		pool.mutex.Lock()
+
+		// The slot may have been refilled while the browser was pinged
+		if pool.closed || pool.browsers[slot] != browser {
+			pool.mutex.Unlock()
+
+			continue
+		}
+
+		if browser != nil {
+			browser.cancel()
+			pool.browsers[slot] = nil
+		}
+
+		// Empty slots are refilled too, so browsers stay warm after a failed start or a recycle
+		replacement := pool.start(slot)
+
+		pool.mutex.Unlock()
+
+		<-replacement.ready
+
+		if replacement.err != nil {
+			pool.logger.WithFields(logrus.Fields{
+				"[op]":    op,
+				"error":   replacement.err.Error(),
+				"message": "failed to replace browser, retrying on the next check or tab",
+			}).Error()
+		}
+	}
+}
+
+// ping asks the browser for its version
+func ping(browser *instance) error {
+	if err := browser.ctx.Err(); err != nil {
+		return fmt.Errorf("browser is gone: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(browser.ctx, healthCheckTimeout)
+	defer cancel()
+
+	return chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		_, _, _, _, _, err := cdpbrowser.GetVersion().Do(ctx)
+
+		return err
+	}))
+}
+
+// started tells whether the browser is up, it is false while starting and after a failed start
+func (browser *instance) started() bool {
+	select {
+	case <-browser.ready:
+		return browser.err == nil
+	default:
+		return false
+	}
+}
+
+// busyness orders browsers for new tabs, empty slots come right after idle browsers
+func busyness(browser *instance) int {
+	if browser == nil {
+		return 1
+	}
+
+	if browser.active == 0 {
+		return 0
+	}
+
+	return browser.active + 1
+}
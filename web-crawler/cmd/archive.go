@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	"web-crawler/archive"
+	"web-crawler/store"
+	"web-crawler/util/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// createArchive returns the page archive selected by the config, nil when archiving is disabled
+func createArchive(
+	logger *logrus.Logger,
+	archiveConfig config.Archive,
+	store store.IStore,
+) (archive.Archive, error) {
+	const op = "[main] createArchive"
+
+	retention := archive.Retention{
+		MaxAge:       archiveConfig.Retention.MaxAge,
+		MaxSnapshots: archiveConfig.Retention.MaxSnapshots,
+	}
+
+	var pageArchive archive.Archive
+
+	switch archiveConfig.Type {
+	case "":
+		logger.WithFields(logrus.Fields{
+			"[op]":    op,
+			"message": "page archive is disabled",
+		}).Info()
+
+		return nil, nil
+	case archive.TypeFilesystem:
+		filesystem, err := archive.NewFilesystem(logger, archiveConfig.Directory, retention)
+		if err != nil {
+			return nil, err
+		}
+
+		pageArchive = filesystem
+	case archive.TypePostgres:
+		pageArchive = archive.NewPostgres(logger, store, retention)
+	default:
+		return nil, fmt.Errorf("unsupported archive type: %q", archiveConfig.Type)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"[op]":      op,
+		"type":      archiveConfig.Type,
+		"retention": fmt.Sprintf("%+v", retention),
+		"message":   "page archive created successfully",
+	}).Info()
+
+	return pageArchive, nil
+}
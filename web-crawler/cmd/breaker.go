@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+
+	"web-crawler/breaker"
+	"web-crawler/util/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// createCircuitBreakers returns the per host circuit breakers around crawls, nil when they are disabled
+func createCircuitBreakers(
+	logger *logrus.Logger,
+	breakerConfig config.CircuitBreaker,
+) *breaker.Breakers {
+	const op = "[main] createCircuitBreakers"
+
+	if breakerConfig.Disabled {
+		logger.WithFields(logrus.Fields{
+			"[op]":    op,
+			"message": "circuit breakers are disabled, failing hosts keep being crawled",
+		}).Warn()
+
+		return nil
+	}
+
+	options := breaker.Options{
+		FailureThreshold: breakerConfig.FailureThreshold,
+		Cooldown:         breakerConfig.Cooldown,
+	}
+
+	logger.WithFields(logrus.Fields{
+		"[op]":    op,
+		"options": fmt.Sprintf("%+v", options),
+		"message": "circuit breakers created successfully",
+	}).Info()
+
+	return breaker.New(logger, options)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+
+	"web-crawler/browser"
+	"web-crawler/util/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// createBrowserPool returns the pool of browsers shared by every browser crawl
+func createBrowserPool(
+	logger *logrus.Logger,
+	browserConfig config.Browser,
+) *browser.Pool {
+	const op = "[main] createBrowserPool"
+
+	options := browser.Options{
+		Browsers: browserConfig.Browsers,
+		MaxTabs:  browserConfig.MaxTabs,
+		MaxUses:  browserConfig.MaxUses,
+
+		RemoteUrl:           browserConfig.RemoteUrl,
+		HealthCheckInterval: browserConfig.HealthCheckInterval,
+		StartTimeout:        browserConfig.StartTimeout,
+	}
+
+	pool := browser.NewPool(logger, options)
+
+	// Browsers are started or connected up front so the first crawl does not pay for it,
+	// a failure is not fatal since static setups never need a browser and health checks retry
+	err := pool.Warm()
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"[op]":    op,
+			"error":   err.Error(),
+			"message": "failed to warm browser pool, browsers are started on first use or health check",
+		}).Warn()
+	}
+
+	logger.WithFields(logrus.Fields{
+		"[op]":    op,
+		"options": fmt.Sprintf("%+v", options),
+		"message": "browser pool created successfully",
+	}).Info()
+
+	return pool
+}
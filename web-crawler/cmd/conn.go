@@ -6,6 +6,8 @@ import (
 
 	"web-crawler/util/config"
 
+	pgxdecimal "github.com/jackc/pgx-shopspring-decimal"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sirupsen/logrus"
 )
@@ -31,6 +33,13 @@ func createPostgresPool(
 	pgConfig.MaxConns = int32(postgresConfig.Pool.MaxConns)
 	pgConfig.MinConns = int32(postgresConfig.Pool.MinConns)
 
+	// Map numeric columns to exact decimals
+	pgConfig.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		pgxdecimal.Register(conn.TypeMap())
+
+		return nil
+	}
+
 	pool, err := pgxpool.NewWithConfig(context.Background(), pgConfig)
 	if err != nil {
 		logger.WithFields(logrus.Fields{
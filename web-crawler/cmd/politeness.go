@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+
+	"web-crawler/politeness"
+	"web-crawler/util/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// createPoliteness returns the politeness layer every page fetch goes through
+func createPoliteness(
+	logger *logrus.Logger,
+	politenessConfig config.Politeness,
+) *politeness.Politeness {
+	const op = "[main] createPoliteness"
+
+	options := politeness.Options{
+		IgnoreRobots:      politenessConfig.IgnoreRobots,
+		UserAgent:         politenessConfig.UserAgent,
+		RequestsPerMinute: politenessConfig.RequestsPerMinute,
+		Burst:             politenessConfig.Burst,
+		RobotsTTL:         politenessConfig.RobotsTTL,
+		MaxWait:           politenessConfig.MaxWait,
+	}
+
+	if options.IgnoreRobots {
+		logger.WithFields(logrus.Fields{
+			"[op]":    op,
+			"message": "robots.txt is ignored, only the per host rate limit applies",
+		}).Warn()
+	}
+
+	logger.WithFields(logrus.Fields{
+		"[op]":    op,
+		"options": fmt.Sprintf("%+v", options),
+		"message": "politeness layer created successfully",
+	}).Info()
+
+	return politeness.New(logger, options)
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"web-crawler/archive"
+	"web-crawler/scheduler"
+	"web-crawler/service"
+	"web-crawler/store"
+	"web-crawler/util/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+// replayResult is the outcome of replaying one snapshot, printed as JSON
+type replayResult struct {
+	Snapshot      string     `json:"snapshot"`
+	SetupId       string     `json:"setup_id,omitempty"`
+	Url           string     `json:"url,omitempty"`
+	CapturedAt    *time.Time `json:"captured_at,omitempty"`
+	Jual          string     `json:"jual,omitempty"`
+	JualLabel     string     `json:"jual_label,omitempty"`
+	Beli          string     `json:"beli,omitempty"`
+	BeliLabel     string     `json:"beli_label,omitempty"`
+	EmasId        string     `json:"emas_id,omitempty"`
+	ObservationId int64      `json:"observation_id,omitempty"`
+
+	// ReplacedObservationIds are the crawled observations of the same page deleted for the written one
+	ReplacedObservationIds []int64 `json:"replaced_observation_ids,omitempty"`
+
+	// FailedAttempt is set for snapshots of failed crawl attempts, NotWritten tells why their prices were kept out of the database
+	FailedAttempt bool   `json:"failed_attempt,omitempty"`
+	NotWritten    string `json:"not_written,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// replaySetup is what a scheduler setup contributes to a replay
+type replaySetup struct {
+	extraction service.ExtractionSpec
+	location   *time.Location
+}
+
+func replay() {
+	const op = "[main] replay"
+
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	setupId := flags.String("setup", "", "extract with the spec of this scheduler setup, and only replay its snapshots when reading the configured archive (default: the setup recorded with the snapshot)")
+	date := flags.String("date", "", "capture date (YYYY-MM-DD) of the snapshots replayed from the configured archive, or of snapshots saved without metadata")
+	confirm := flags.Bool("confirm", false, "replace the crawled observations with the extracted prices and roll up ibdwh.emas and ibdwh.emas_ohlc again")
+	repairFailed := flags.Bool("repair-failed", false, "with -confirm, also write snapshots of attempts that got the page but failed to parse it or check its prices")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: web-crawler replay [-setup id] [-date YYYY-MM-DD] [-confirm [-repair-failed]] [file or directory]")
+		fmt.Fprintln(os.Stderr, "Without a file or directory, the snapshots are read from the configured archive")
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(flag.Args()[1:])
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	// --- Init logger, stdout is kept for the results ---
+	var logger = logrus.New()
+	logger.Formatter = new(logrus.TextFormatter)
+	logger.Formatter.(*logrus.TextFormatter).DisableColors = true
+	logger.Formatter.(*logrus.TextFormatter).DisableTimestamp = true
+	logger.Level = logrus.InfoLevel
+	logger.Out = os.Stderr
+
+	fail := func(scope string, err error) {
+		logger.WithFields(logrus.Fields{
+			"[op]":  op,
+			"scope": scope,
+			"error": err.Error(),
+		}).Error()
+
+		os.Exit(1)
+	}
+
+	// Snapshots come from the configured archive unless files are given
+	fromArchive := flags.NArg() == 0
+
+	// --- Load config, only required to pick a setup, read the archive or write rows ---
+	config, err := config.LoadConfig(".")
+	if err != nil {
+		if *setupId != "" || *confirm || fromArchive {
+			fail("LoadConfig", err)
+		}
+
+		logger.WithFields(logrus.Fields{
+			"[op]":    op,
+			"message": "No configuration loaded, replaying with the default extraction spec",
+			"error":   err.Error(),
+		}).Warn()
+	}
+
+	setups := map[string]*replaySetup{}
+	for _, setup := range config.Scheduler.Setups {
+		setups[setup.Id] = &replaySetup{
+			extraction: scheduler.ExtractionSpec(setup.Extraction),
+			location:   scheduler.Location(setup.Timezone),
+		}
+	}
+
+	if *setupId != "" && setups[*setupId] == nil {
+		fail("setup", fmt.Errorf("unknown setup: %q", *setupId))
+	}
+
+	var captureDate time.Time
+	if *date != "" {
+		captureDate, err = time.ParseInLocation("2006-01-02", *date, time.Local)
+		if err != nil {
+			fail("date", fmt.Errorf("invalid date: %w", err))
+		}
+	}
+
+	// --- Init store layer, only when rows are written or snapshots are read from postgres ---
+	var crawlerStore store.IStore
+	if *confirm || (fromArchive && config.Archive.Type == archive.TypePostgres) {
+		postgresPool, err := createPostgresPool(logger, config.DB.Postgres)
+		if err != nil {
+			fail("createPostgresPool", err)
+		}
+		defer postgresPool.Close()
+
+		crawlerStore = store.NewStore(logger, postgresPool)
+	}
+
+	// --- Init service layer without archive nor browsers, replays are never archived or fetched ---
+	crawlerService := service.NewService(logger, crawlerStore, nil, nil, nil, nil)
+
+	ctx := context.Background()
+
+	replaySnapshot := func(snapshot *archive.Snapshot) replayResult {
+		result := replayResult{
+			Snapshot: snapshot.Source,
+			SetupId:  snapshot.SetupId,
+			Url:      snapshot.Url,
+		}
+
+		id := *setupId
+		if id == "" {
+			id = snapshot.SetupId
+		}
+
+		params := &service.ReplayEmasParams{
+			Content:    snapshot.Content,
+			SetupId:    id,
+			Url:        snapshot.Url,
+			CreatedAt:  snapshot.CapturedAt,
+			CapturedAt: snapshot.CapturedAt,
+			Write:      *confirm,
+		}
+
+		if params.CreatedAt.IsZero() {
+			params.CreatedAt = captureDate
+		}
+
+		// Error and block pages must never turn into prices
+		result.FailedAttempt = snapshot.Error != "" || (snapshot.StatusCode != 0 && !successStatus(snapshot.StatusCode))
+		if reason := notWritten(snapshot, *repairFailed); *confirm && reason != "" {
+			params.Write = false
+			result.NotWritten = reason
+		}
+
+		if setup := setups[id]; setup != nil {
+			params.Extraction = setup.extraction
+
+			// The emas row is picked by the date in the setup's timezone
+			if !snapshot.CapturedAt.IsZero() {
+				params.CreatedAt = params.CreatedAt.In(setup.location)
+			}
+		}
+
+		if !params.CreatedAt.IsZero() {
+			result.CapturedAt = &params.CreatedAt
+		}
+
+		replayed, err := crawlerService.ReplayEmas(ctx, params)
+		if err != nil {
+			result.Error = err.Error()
+
+			return result
+		}
+
+		result.Jual = replayed.Jual.String()
+		result.JualLabel = replayed.JualLabel
+		result.Beli = replayed.Beli.String()
+		result.BeliLabel = replayed.BeliLabel
+		result.EmasId = replayed.ID
+		result.ObservationId = replayed.ObservationID
+		result.ReplacedObservationIds = replayed.ReplacedObservationIDs
+
+		return result
+	}
+
+	var results []replayResult
+
+	// Snapshots are replayed in capture order, so the latest snapshot of a day is written last
+	if fromArchive {
+		pageArchive, err := createArchive(logger, config.Archive, crawlerStore)
+		if err != nil {
+			fail("createArchive", err)
+		}
+
+		if pageArchive == nil {
+			fail("createArchive", fmt.Errorf("no page archive is configured, give a file or directory to replay"))
+		}
+
+		filter := archive.Filter{
+			SetupId: *setupId,
+		}
+
+		if !captureDate.IsZero() {
+			filter.From = captureDate
+			filter.To = captureDate.AddDate(0, 0, 1)
+		}
+
+		snapshots, err := pageArchive.List(ctx, filter)
+		if err != nil {
+			fail("List", err)
+		}
+
+		results = make([]replayResult, 0, len(snapshots))
+		for _, snapshot := range snapshots {
+			results = append(results, replaySnapshot(snapshot))
+		}
+	} else {
+		files, err := archive.ListFiles(flags.Arg(0))
+		if err != nil {
+			fail("ListFiles", err)
+		}
+
+		results = make([]replayResult, 0, len(files))
+		for _, file := range files {
+			snapshot, err := archive.ReadFile(file)
+			if err != nil {
+				results = append(results, replayResult{
+					Snapshot: file,
+					Error:    err.Error(),
+				})
+
+				continue
+			}
+
+			results = append(results, replaySnapshot(snapshot))
+		}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	err = encoder.Encode(results)
+	if err != nil {
+		fail("Encode", err)
+	}
+}
+
+// notWritten tells why the prices of a snapshot must be kept out of the database, empty when they may be written.
+// Pages that came back fine but broke the parser are written with repairFailed, the point of a parser fix
+func notWritten(snapshot *archive.Snapshot, repairFailed bool) string {
+	if snapshot.StatusCode != 0 && !successStatus(snapshot.StatusCode) {
+		return fmt.Sprintf("snapshot of a failed attempt, status %d", snapshot.StatusCode)
+	}
+
+	if snapshot.Error == "" {
+		return ""
+	}
+
+	// Snapshots archived before failures were classified are never written
+	class := service.ErrorClass(snapshot.ErrorClass)
+	if class == "" {
+		class = service.ErrorClassUnknown
+	}
+
+	switch class {
+	case service.ErrorClassParse, service.ErrorClassSanity:
+		if repairFailed {
+			return ""
+		}
+
+		return fmt.Sprintf("snapshot of a failed attempt (%s error), use -repair-failed to write it", class)
+	default:
+		return fmt.Sprintf("snapshot of a failed attempt (%s error)", class)
+	}
+}
+
+func successStatus(statusCode int) bool {
+	return statusCode >= 200 && statusCode <= 299
+}
@@ -71,8 +71,29 @@ func start() {
 	// --- Init store layer ---
 	store := store.NewStore(logger, postgresPool)
 
+	// --- Init page archive ---
+	pageArchive, err := createArchive(logger, config.Archive, store)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"[op]":  op,
+			"error": err.Error(),
+		}).Error()
+
+		os.Exit(1)
+	}
+
+	// --- Init browser pool ---
+	browserPool := createBrowserPool(logger, config.Browser)
+	defer browserPool.Close()
+
+	// --- Init politeness layer ---
+	politeness := createPoliteness(logger, config.Politeness)
+
+	// --- Init circuit breakers ---
+	breakers := createCircuitBreakers(logger, config.CircuitBreaker)
+
 	// --- Init service layer ---
-	service := service.NewService(logger, store)
+	service := service.NewService(logger, store, pageArchive, browserPool, politeness, breakers)
 
 	// --- Init scheduler ---
 	scheduler := scheduler.NewScheduler(logger, config.Scheduler.Setups, service)
@@ -0,0 +1,285 @@
+package politeness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultUserAgent is the product token looked up in robots.txt when the options leave it unset
+	DefaultUserAgent = "web-crawler"
+
+	// DefaultRequestsPerMinute is the per host request rate when the options leave it unset
+	DefaultRequestsPerMinute = 12
+
+	// DefaultBurst is how many requests may go out to a host back to back when the options leave it unset
+	DefaultBurst = 2
+
+	// DefaultRobotsTTL is how long a robots.txt is cached when the options leave it unset
+	DefaultRobotsTTL = 24 * time.Hour
+
+	// DefaultMaxWait is the longest a fetch may wait for its host's rate limit when the options leave it unset
+	DefaultMaxWait = time.Minute
+
+	// robotsTimeout bounds the fetch of a robots.txt
+	robotsTimeout = 10 * time.Second
+
+	// maxRobotsSize limits how much of a robots.txt is read, as RFC 9309 allows
+	maxRobotsSize = 500 << 10
+)
+
+var (
+	// ErrDisallowed is returned for URLs that robots.txt does not let the crawler fetch
+	ErrDisallowed = errors.New("disallowed by robots.txt")
+
+	// ErrRateLimited is returned when a fetch would have to wait longer than the max wait for its host
+	ErrRateLimited = errors.New("per host rate limit exceeded")
+)
+
+// IsViolation reports whether the error is a politeness violation, fetches failing with one must not be retried
+func IsViolation(err error) bool {
+	return errors.Is(err, ErrDisallowed) || errors.Is(err, ErrRateLimited)
+}
+
+// Options configures the politeness layer, zero values fall back to the defaults
+type Options struct {
+	// IgnoreRobots skips robots.txt, the rate limit still applies
+	IgnoreRobots bool
+
+	// UserAgent is the product token whose robots.txt group applies, "*" groups are used when none names it
+	UserAgent string
+
+	RequestsPerMinute float64
+	Burst             int
+
+	RobotsTTL time.Duration
+	MaxWait   time.Duration
+}
+
+// host is the politeness state of one scheme and host
+type host struct {
+	mutex sync.Mutex
+
+	limiter *rate.Limiter
+
+	robots    *robots
+	fetchedAt time.Time
+}
+
+// Politeness lets a fetch go out only when robots.txt allows it and the host's token bucket has room.
+//
+// robots.txt is fetched on the first request to a host and cached for
+// RobotsTTL. A crawl-delay slows the host's bucket down below RequestsPerMinute.
+// When robots.txt cannot be fetched, the cached copy is used, or the fetch
+// fails without being a violation so that it is retried.
+type Politeness struct {
+	logger *logrus.Logger
+
+	options Options
+
+	httpClient *http.Client
+
+	mutex sync.Mutex
+	hosts map[string]*host
+}
+
+func New(logger *logrus.Logger, options Options) *Politeness {
+	if options.UserAgent == "" {
+		options.UserAgent = DefaultUserAgent
+	}
+
+	if options.RequestsPerMinute <= 0 {
+		options.RequestsPerMinute = DefaultRequestsPerMinute
+	}
+
+	if options.Burst <= 0 {
+		options.Burst = DefaultBurst
+	}
+
+	if options.RobotsTTL <= 0 {
+		options.RobotsTTL = DefaultRobotsTTL
+	}
+
+	if options.MaxWait <= 0 {
+		options.MaxWait = DefaultMaxWait
+	}
+
+	return &Politeness{
+		logger: logger,
+
+		options: options,
+
+		httpClient: &http.Client{},
+
+		hosts: map[string]*host{},
+	}
+}
+
+// Wait blocks until the URL may be fetched, it fails with ErrDisallowed or ErrRateLimited on violations.
+// robots.txt is fetched with the client, so that it goes out through the same proxy as the page, nil fetches it directly
+func (politeness *Politeness) Wait(ctx context.Context, rawUrl string, client *http.Client) error {
+	const op = "[politeness] Politeness.Wait"
+
+	target, err := url.Parse(rawUrl)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+
+	host := politeness.host(target)
+
+	if !politeness.options.IgnoreRobots {
+		robots, err := politeness.robots(ctx, target, host, client)
+		if err != nil {
+			return err
+		}
+
+		if !robots.allowed(target.RequestURI()) {
+			return fmt.Errorf("%w: %s (user agent %q)", ErrDisallowed, rawUrl, politeness.options.UserAgent)
+		}
+	}
+
+	reservation := host.limiter.Reserve()
+	if !reservation.OK() {
+		return fmt.Errorf("%w: %s", ErrRateLimited, target.Host)
+	}
+
+	delay := reservation.Delay()
+	if delay > politeness.options.MaxWait {
+		reservation.Cancel()
+
+		return fmt.Errorf("%w: %s would wait %s, more than %s", ErrRateLimited, target.Host, delay.Round(time.Second), politeness.options.MaxWait)
+	}
+
+	if delay > 0 {
+		politeness.logger.WithFields(logrus.Fields{
+			"[op]":         op,
+			"host":         target.Host,
+			"wait_seconds": delay.Seconds(),
+			"message":      "waiting for the host's rate limit",
+		}).Info()
+
+		select {
+		case <-ctx.Done():
+			reservation.Cancel()
+
+			return fmt.Errorf("failed to wait for the host's rate limit: %w", ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+
+	return nil
+}
+
+// host returns the politeness state of the URL's scheme and host
+func (politeness *Politeness) host(target *url.URL) *host {
+	key := strings.ToLower(target.Scheme + "://" + target.Host)
+
+	politeness.mutex.Lock()
+	defer politeness.mutex.Unlock()
+
+	existing := politeness.hosts[key]
+	if existing != nil {
+		return existing
+	}
+
+	created := &host{
+		limiter: rate.NewLimiter(rate.Limit(politeness.options.RequestsPerMinute/60), politeness.options.Burst),
+	}
+
+	politeness.hosts[key] = created
+
+	return created
+}
+
+// robots returns the cached robots.txt rules of the host, fetching them when missing or expired
+func (politeness *Politeness) robots(ctx context.Context, target *url.URL, host *host, client *http.Client) (*robots, error) {
+	const op = "[politeness] Politeness.robots"
+
+	host.mutex.Lock()
+	defer host.mutex.Unlock()
+
+	if host.robots != nil && time.Since(host.fetchedAt) < politeness.options.RobotsTTL {
+		return host.robots, nil
+	}
+
+	robotsUrl := target.Scheme + "://" + target.Host + "/robots.txt"
+
+	robots, err := politeness.fetchRobots(ctx, robotsUrl, client)
+	if err != nil {
+		if host.robots != nil {
+			politeness.logger.WithFields(logrus.Fields{
+				"[op]":       op,
+				"robots_url": robotsUrl,
+				"error":      err.Error(),
+				"message":    "failed to refresh robots.txt, keeping the cached copy",
+			}).Warn()
+
+			return host.robots, nil
+		}
+
+		return nil, fmt.Errorf("failed to fetch robots.txt: %w", err)
+	}
+
+	host.robots = robots
+	host.fetchedAt = time.Now()
+
+	// A crawl-delay slows the host down below the configured rate
+	limit := rate.Limit(politeness.options.RequestsPerMinute / 60)
+	if robots.crawlDelay > 0 {
+		limit = min(limit, rate.Every(robots.crawlDelay))
+	}
+
+	host.limiter.SetLimit(limit)
+
+	politeness.logger.WithFields(logrus.Fields{
+		"[op]":                op,
+		"robots_url":          robotsUrl,
+		"rules":               len(robots.rules),
+		"crawl_delay_seconds": robots.crawlDelay.Seconds(),
+		"message":             "robots.txt fetched",
+	}).Info()
+
+	return robots, nil
+}
+
+// fetchRobots downloads and parses a robots.txt, a missing one (4xx) allows everything
+func (politeness *Politeness) fetchRobots(ctx context.Context, robotsUrl string, client *http.Client) (*robots, error) {
+	ctx, cancel := context.WithTimeout(ctx, robotsTimeout)
+	defer cancel()
+
+	if client == nil {
+		client = politeness.httpClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsUrl, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", politeness.options.UserAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return parseRobots(io.LimitReader(resp.Body, maxRobotsSize), politeness.options.UserAgent)
+	case resp.StatusCode >= 400 && resp.StatusCode <= 499:
+		return &robots{}, nil
+	default:
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,180 @@
+package politeness
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robotsRule is an allow or disallow line of a robots.txt group
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// robots holds the rules of a robots.txt that apply to the crawler's user agent
+type robots struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// robotsGroup is a set of rules sharing the same user-agent lines
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+
+	// closed is set by the first line after the user-agent lines, even one adding no rule like an empty disallow
+	closed bool
+}
+
+// parseRobots reads a robots.txt and keeps the groups matching the user agent,
+// falling back to the "*" groups as described in RFC 9309.
+// A body that could not be read fails, a partial robots.txt could allow what the rest disallows
+func parseRobots(body io.Reader, userAgent string) (*robots, error) {
+	var groups []*robotsGroup
+	var current *robotsGroup
+
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// Consecutive user-agent lines share the rules that follow them
+			if current == nil || current.closed {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+			}
+
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			if current == nil {
+				continue
+			}
+
+			current.closed = true
+
+			// An empty disallow allows everything, it is no rule at all
+			if value == "" {
+				continue
+			}
+
+			current.rules = append(current.rules, robotsRule{
+				allow:   key == "allow",
+				pattern: value,
+			})
+		case "crawl-delay":
+			if current == nil {
+				continue
+			}
+
+			current.closed = true
+
+			seconds, err := strconv.ParseFloat(value, 64)
+			if err == nil && seconds > 0 {
+				current.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read robots.txt: %w", err)
+	}
+
+	userAgent = strings.ToLower(userAgent)
+
+	result := &robots{}
+	for _, wildcard := range []bool{false, true} {
+		matched := false
+
+		for _, group := range groups {
+			for _, agent := range group.agents {
+				if (!wildcard && agent == userAgent) || (wildcard && agent == "*") {
+					result.rules = append(result.rules, group.rules...)
+					result.crawlDelay = max(result.crawlDelay, group.crawlDelay)
+					matched = true
+
+					break
+				}
+			}
+		}
+
+		// Groups naming the crawler replace the "*" groups, even when they have no rules
+		if matched {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// allowed reports whether the path (with its query) may be fetched, the longest matching rule wins and allow wins ties
+func (robots *robots) allowed(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+
+	allow := true
+	longest := -1
+
+	for _, rule := range robots.rules {
+		if !matchRobotsPattern(rule.pattern, path) {
+			continue
+		}
+
+		length := len(rule.pattern)
+		if length > longest || (length == longest && rule.allow) {
+			longest = length
+			allow = rule.allow
+		}
+	}
+
+	return allow
+}
+
+// matchRobotsPattern matches a path against a rule where "*" is any sequence and a final "$" anchors the end
+func matchRobotsPattern(pattern string, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = strings.TrimSuffix(pattern, "$")
+	}
+
+	parts := strings.Split(pattern, "*")
+
+	// The first part is a prefix of the path
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+
+	position := len(parts[0])
+	for i, part := range parts[1:] {
+		// The last part of an anchored pattern must end the path
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(path[position:], part)
+		}
+
+		index := strings.Index(path[position:], part)
+		if index < 0 {
+			return false
+		}
+
+		position += index + len(part)
+	}
+
+	return !anchored || position == len(path)
+}
@@ -0,0 +1,73 @@
+package politeness
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRobotsGroups(t *testing.T) {
+	tests := []struct {
+		name       string
+		robots     string
+		path       string
+		allowed    bool
+		crawlDelay time.Duration
+	}{
+		{
+			name:    "no group names the crawler",
+			robots:  "User-agent: *\nDisallow: /\n",
+			path:    "/harga",
+			allowed: false,
+		},
+		{
+			name:    "named group replaces the wildcard group",
+			robots:  "User-agent: web-crawler\nDisallow: /private\n\nUser-agent: *\nDisallow: /\n",
+			path:    "/harga",
+			allowed: true,
+		},
+		{
+			name:    "empty named group replaces a restrictive wildcard group",
+			robots:  "User-agent: web-crawler\nDisallow:\n\nUser-agent: *\nDisallow: /\n",
+			path:    "/harga",
+			allowed: true,
+		},
+		{
+			name:    "empty named group after the wildcard group",
+			robots:  "User-agent: *\nDisallow: /\n\nUser-agent: web-crawler\nAllow:\n",
+			path:    "/harga",
+			allowed: true,
+		},
+		{
+			name:       "wildcard crawl delay is ignored for a named group",
+			robots:     "User-agent: web-crawler\nDisallow:\n\nUser-agent: *\nCrawl-delay: 10\n",
+			path:       "/harga",
+			allowed:    true,
+			crawlDelay: 0,
+		},
+		{
+			name:       "user agents sharing a group",
+			robots:     "User-agent: other\nUser-agent: Web-Crawler\nDisallow: /harga\nCrawl-delay: 2\n",
+			path:       "/harga",
+			allowed:    false,
+			crawlDelay: 2 * time.Second,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			robots, err := parseRobots(strings.NewReader(test.robots), "web-crawler")
+			if err != nil {
+				t.Fatalf("parseRobots() error = %v", err)
+			}
+
+			if got := robots.allowed(test.path); got != test.allowed {
+				t.Errorf("allowed(%q) = %v, want %v", test.path, got, test.allowed)
+			}
+
+			if robots.crawlDelay != test.crawlDelay {
+				t.Errorf("crawlDelay = %v, want %v", robots.crawlDelay, test.crawlDelay)
+			}
+		})
+	}
+}
@@ -2,7 +2,7 @@ package scheduler
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"time"
 
 	"web-crawler/service"
@@ -52,22 +52,8 @@ func (scheduler *Scheduler) RunEmas(setup config.SchedulerSetup) {
 			return
 
 		case tickTime := <-ticker.C:
-			// Convert tickTime to configured timezone
-			loc, err := time.LoadLocation(setup.Timezone)
-			if err != nil {
-				// Try parsing as UTC offset (e.g., "+07", "-05")
-				if offset, offsetErr := time.Parse("-07", setup.Timezone); offsetErr == nil {
-					// Create a fixed timezone with the parsed offset
-					offsetSeconds := int(offset.Sub(time.Time{}).Seconds())
-					loc = time.FixedZone(fmt.Sprintf("UTC%s", setup.Timezone), offsetSeconds)
-				} else {
-					// Fallback to UTC if parsing fails
-					loc = time.UTC
-				}
-			}
-
 			// Convert tickTime to the configured timezone
-			localTickTime := tickTime.In(loc)
+			localTickTime := tickTime.In(Location(setup.Timezone))
 
 			logger.WithFields(logrus.Fields{
 				"tick_time_utc":   tickTime.Format("2006-01-02 15:04:05 MST"),
@@ -113,6 +99,7 @@ func (scheduler *Scheduler) RunEmas(setup config.SchedulerSetup) {
 
 				// Execute the scraping
 				result, err := scheduler.service.CreateEmas(ctx, &service.CreateEmasParams{
+					SetupId:   setup.Id,
 					Url:       setup.Url,
 					CreatedAt: localTickTime,
 					Retry: service.RetryConfig{
@@ -121,19 +108,42 @@ func (scheduler *Scheduler) RunEmas(setup config.SchedulerSetup) {
 						MaxDelay:      setup.Retry.MaxDelay,
 						BackoffFactor: setup.Retry.BackoffFactor,
 						EnableJitter:  setup.Retry.EnableJitter,
+						Strategy:      setup.Retry.Strategy,
+
+						IgnoreRetryAfter: setup.Retry.IgnoreRetryAfter,
+						MaxRetryAfter:    setup.Retry.MaxRetryAfter,
+
+						Policies: RetryPolicies(setup.Retry.Policies),
 					},
+					Extraction: ExtractionSpec(setup.Extraction),
+					FetchMode:  setup.FetchMode,
+					Readiness:  ReadinessSpec(setup.Readiness),
+					Blocking:   BlockingSpec(setup.Blocking),
+					Rotation:   RotationSpec(setup.Rotation),
+					Steps:      BrowserSteps(setup.Steps),
+					Session:    SessionSpec(setup.Session),
+					Emulation:  EmulationSpec(setup.Emulation),
+					Timeouts:   TimeoutSpec(setup.Timeouts),
 				})
 
 				jobDuration := time.Since(jobStartTime)
 
-				if err != nil {
+				if errors.Is(err, service.ErrCrawlSkipped) {
+					logger.WithFields(logrus.Fields{
+						"message":              "Scraping job skipped, the circuit breaker of the host is open",
+						"error":                err.Error(),
+						"job_duration_seconds": jobDuration.Seconds(),
+					}).Warn()
+				} else if err != nil {
 					logger.WithFields(logrus.Fields{
 						"error":                err.Error(),
+						"error_class":          service.ClassOf(err),
 						"job_duration_seconds": jobDuration.Seconds(),
 					}).Error()
 				} else {
 					logger.WithFields(logrus.Fields{
 						"emas_id":              result.ID,
+						"observation_id":       result.ObservationID,
 						"job_duration_seconds": jobDuration.Seconds(),
 					}).Info()
 				}
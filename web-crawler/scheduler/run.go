@@ -22,9 +22,25 @@ func (service *Scheduler) Run() {
 				go service.RunEmas(setup)
 			}
 		default:
-			err := fmt.Errorf("unrecognized setup id: %s", setup.Id)
+			// Setups declaring their own extraction spec are crawled like the gold price setup
+			spec := ExtractionSpec(setup.Extraction)
+			if spec.IsZero() {
+				err := fmt.Errorf("unrecognized setup id: %s", setup.Id)
 
-			logger.WithError(err).Error()
+				logger.WithError(err).Error()
+
+				continue
+			}
+
+			if err := spec.Validate(); err != nil {
+				err = fmt.Errorf("invalid extraction spec for setup id %s: %w", setup.Id, err)
+
+				logger.WithError(err).Error()
+
+				continue
+			}
+
+			go service.RunEmas(setup)
 		}
 	}
 }
@@ -0,0 +1,187 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"web-crawler/service"
+	"web-crawler/util/config"
+
+	"github.com/shopspring/decimal"
+)
+
+// Location returns the time zone of a setup, either a named zone or a UTC offset like "+07", falling back to UTC
+func Location(timezone string) *time.Location {
+	loc, err := time.LoadLocation(timezone)
+	if err == nil {
+		return loc
+	}
+
+	// Try parsing as UTC offset (e.g., "+07", "-05")
+	offset, err := time.Parse("-07", timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	// Create a fixed timezone with the parsed offset
+	offsetSeconds := int(offset.Sub(time.Time{}).Seconds())
+
+	return time.FixedZone(fmt.Sprintf("UTC%s", timezone), offsetSeconds)
+}
+
+// ExtractionSpec converts the extraction config of a setup into a service extraction spec
+func ExtractionSpec(extraction config.ExtractionConfig) service.ExtractionSpec {
+	return service.ExtractionSpec{
+		Labels: service.ExtractionLabels{
+			Jual:     extraction.Labels.Jual,
+			Beli:     extraction.Labels.Beli,
+			Pattern:  extraction.Labels.Pattern,
+			MaxDepth: extraction.Labels.MaxDepth,
+		},
+		Jual:           extractionRules(extraction.Jual),
+		Beli:           extractionRules(extraction.Beli),
+		Prices:         extractionRules(extraction.Prices),
+		UnitMultiplier: decimal.NewFromFloat(extraction.UnitMultiplier),
+		Locale:         extraction.Locale,
+		Product:        extraction.Product,
+		Products:       extractionProducts(extraction.Products),
+	}
+}
+
+// ReadinessSpec converts the readiness config of a setup into a service readiness spec
+func ReadinessSpec(readiness config.ReadinessConfig) service.ReadinessSpec {
+	return service.ReadinessSpec{
+		Selector:    readiness.Selector,
+		Text:        readiness.Text,
+		NetworkIdle: readiness.NetworkIdle,
+		Js:          readiness.Js,
+		MaxWait:     readiness.MaxWait,
+	}
+}
+
+// BlockingSpec converts the blocking config of a setup into a service blocking spec
+func BlockingSpec(blocking config.BlockingConfig) service.BlockingSpec {
+	return service.BlockingSpec{
+		ResourceTypes: blocking.ResourceTypes,
+		Domains:       blocking.Domains,
+	}
+}
+
+// RotationSpec converts the rotation config of a setup into a service rotation spec
+func RotationSpec(rotation config.RotationConfig) service.RotationSpec {
+	return service.RotationSpec{
+		Proxies:     rotation.Proxies,
+		UserAgents:  rotation.UserAgents,
+		Strategy:    rotation.Strategy,
+		MaxFailures: rotation.MaxFailures,
+		Cooldown:    rotation.Cooldown,
+	}
+}
+
+// BrowserSteps converts the step list of a setup into service browser steps
+func BrowserSteps(steps []config.StepConfig) []service.BrowserStep {
+	result := make([]service.BrowserStep, 0, len(steps))
+
+	for _, step := range steps {
+		result = append(result, service.BrowserStep{
+			Action:   step.Action,
+			Selector: step.Selector,
+			Value:    step.Value,
+			Timeout:  step.Timeout,
+		})
+	}
+
+	return result
+}
+
+// SessionSpec converts the session config of a setup into a service session spec
+func SessionSpec(session config.SessionConfig) service.SessionSpec {
+	return service.SessionSpec{
+		Persist: session.Persist,
+		Login: service.LoginSpec{
+			Url:              session.Login.Url,
+			UsernameSelector: session.Login.UsernameSelector,
+			PasswordSelector: session.Login.PasswordSelector,
+			SubmitSelector:   session.Login.SubmitSelector,
+			LoggedInSelector: session.Login.LoggedInSelector,
+			UsernameEnv:      session.Login.UsernameEnv,
+			PasswordEnv:      session.Login.PasswordEnv,
+			Timeout:          session.Login.Timeout,
+		},
+	}
+}
+
+// EmulationSpec converts the emulation config of a setup into a service emulation spec
+func EmulationSpec(emulation config.EmulationConfig) service.EmulationSpec {
+	spec := service.EmulationSpec{
+		Timezone:       emulation.Timezone,
+		AcceptLanguage: emulation.AcceptLanguage,
+		Device:         emulation.Device,
+		Width:          emulation.Width,
+		Height:         emulation.Height,
+		Scale:          emulation.Scale,
+	}
+
+	if emulation.Geolocation != nil {
+		spec.Geolocation = &service.GeolocationSpec{
+			Latitude:  emulation.Geolocation.Latitude,
+			Longitude: emulation.Geolocation.Longitude,
+			Accuracy:  emulation.Geolocation.Accuracy,
+		}
+	}
+
+	return spec
+}
+
+// TimeoutSpec converts the timeouts of a setup into a service timeout spec
+func TimeoutSpec(timeouts config.TimeoutConfig) service.TimeoutSpec {
+	return service.TimeoutSpec{
+		Navigation: timeouts.Navigation,
+		Extraction: timeouts.Extraction,
+		Attempt:    timeouts.Attempt,
+		Job:        timeouts.Job,
+	}
+}
+
+// RetryPolicies converts the per error class retry policies of a setup into service retry policies
+func RetryPolicies(policies map[string]config.RetryPolicyConfig) map[service.ErrorClass]service.RetryPolicy {
+	result := make(map[service.ErrorClass]service.RetryPolicy, len(policies))
+
+	for class, policy := range policies {
+		result[service.ErrorClass(class)] = service.RetryPolicy{
+			MaxAttempts:  policy.MaxAttempts,
+			InitialDelay: policy.InitialDelay,
+		}
+	}
+
+	return result
+}
+
+func extractionProducts(products []config.ExtractionProductConfig) []service.ExtractionProduct {
+	result := make([]service.ExtractionProduct, 0, len(products))
+
+	for _, product := range products {
+		result = append(result, service.ExtractionProduct{
+			Name:    product.Name,
+			Labels:  product.Labels,
+			Rules:   extractionRules(product.Rules),
+			Pattern: product.Pattern,
+		})
+	}
+
+	return result
+}
+
+func extractionRules(rules []config.ExtractionRule) []service.ExtractionRule {
+	result := make([]service.ExtractionRule, 0, len(rules))
+
+	for _, rule := range rules {
+		result = append(result, service.ExtractionRule{
+			Type:       rule.Type,
+			Expression: rule.Expression,
+			Pattern:    rule.Pattern,
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+
+	"web-crawler/archive"
+
+	"github.com/sirupsen/logrus"
+)
+
+// archivePages stores the raw pages fetched by a crawl attempt
+// Archiving is best effort, failures are logged and never fail the crawl
+func (service *Service) archivePages(ctx context.Context, target *crawlTarget, attempt *crawlAttempt, logger *logrus.Entry) {
+	if service.archive == nil {
+		return
+	}
+
+	for _, page := range attempt.Pages {
+		snapshot := &archive.Snapshot{
+			SetupId:    target.SetupId,
+			Url:        target.Url,
+			Attempt:    attempt.Number,
+			FetchMode:  page.FetchMode,
+			StatusCode: page.StatusCode,
+			Proxy:      page.Proxy,
+			UserAgent:  page.UserAgent,
+			Content:    page.Content,
+			CapturedAt: page.FetchedAt,
+		}
+
+		if page.Err != nil {
+			snapshot.Error = page.Err.Error()
+			snapshot.ErrorClass = string(classifyError(page.Err, page.StatusCode, page.Content))
+		}
+
+		err := service.archive.Save(ctx, snapshot)
+		if err != nil {
+			logger.WithFields(logrus.Fields{
+				"fetch_mode": page.FetchMode,
+				"error":      err,
+			}).Warn("Failed to archive page snapshot")
+
+			continue
+		}
+
+		logger.WithFields(logrus.Fields{
+			"fetch_mode":     page.FetchMode,
+			"status_code":    page.StatusCode,
+			"content_length": len(page.Content),
+		}).Debug("Archived page snapshot")
+	}
+}
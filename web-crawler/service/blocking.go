@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/sirupsen/logrus"
+)
+
+// blockableResourceTypes are the resource types a blocking spec may name, the page document itself is never blocked
+var blockableResourceTypes = []network.ResourceType{
+	network.ResourceTypeStylesheet,
+	network.ResourceTypeImage,
+	network.ResourceTypeMedia,
+	network.ResourceTypeFont,
+	network.ResourceTypeScript,
+	network.ResourceTypeTextTrack,
+	network.ResourceTypeXHR,
+	network.ResourceTypeFetch,
+	network.ResourceTypePrefetch,
+	network.ResourceTypeEventSource,
+	network.ResourceTypeWebSocket,
+	network.ResourceTypeManifest,
+	network.ResourceTypePing,
+	network.ResourceTypeOther,
+}
+
+// BlockingSpec declares the requests a browser crawl does not let through.
+//
+// ResourceTypes are matched case-insensitively against the browser resource
+// types ("image", "font", "media", ...). Domains block every request to the
+// domain and its subdomains, such as analytics or ad servers. The page itself
+// is always loaded.
+type BlockingSpec struct {
+	ResourceTypes []string
+	Domains       []string
+}
+
+// IsZero reports whether nothing is blocked
+func (spec BlockingSpec) IsZero() bool {
+	return len(spec.ResourceTypes) == 0 && len(spec.Domains) == 0
+}
+
+// Validate checks that every resource type is known and every domain is a plain host name
+func (spec BlockingSpec) Validate() error {
+	for _, name := range spec.ResourceTypes {
+		if _, err := blockableResourceType(name); err != nil {
+			return err
+		}
+	}
+
+	for _, domain := range spec.Domains {
+		if strings.TrimSpace(domain) == "" || strings.ContainsAny(domain, "/:*? ") {
+			return fmt.Errorf("invalid domain: %q", domain)
+		}
+	}
+
+	return nil
+}
+
+// patterns returns the request patterns the browser intercepts to block them
+func (spec BlockingSpec) patterns() []*fetch.RequestPattern {
+	var patterns []*fetch.RequestPattern
+
+	for _, name := range spec.ResourceTypes {
+		resourceType, err := blockableResourceType(name)
+		if err != nil {
+			continue
+		}
+
+		patterns = append(patterns, &fetch.RequestPattern{
+			URLPattern:   "*",
+			ResourceType: resourceType,
+		})
+	}
+
+	for _, domain := range spec.Domains {
+		domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+
+		patterns = append(patterns,
+			&fetch.RequestPattern{URLPattern: "*://" + domain + "/*"},
+			&fetch.RequestPattern{URLPattern: "*://*." + domain + "/*"},
+		)
+	}
+
+	return patterns
+}
+
+// blocks reports whether a request of the given type and URL is blocked, the page document never is
+func (spec BlockingSpec) blocks(resourceType network.ResourceType, requestUrl string) bool {
+	if resourceType == network.ResourceTypeDocument {
+		return false
+	}
+
+	for _, name := range spec.ResourceTypes {
+		if strings.EqualFold(name, resourceType.String()) {
+			return true
+		}
+	}
+
+	parsed, err := url.Parse(requestUrl)
+	if err != nil {
+		return false
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	for _, domain := range spec.Domains {
+		domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// blockableResourceType returns the browser resource type of a configured name
+func blockableResourceType(name string) (network.ResourceType, error) {
+	for _, resourceType := range blockableResourceTypes {
+		if strings.EqualFold(name, resourceType.String()) {
+			return resourceType, nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported resource type: %q", name)
+}
+
+// blockedRequests counts the requests failed by a blocking spec
+type blockedRequests struct {
+	count atomic.Int64
+}
+
+// interceptRequests blocks the requests matched by the spec in the tab and answers the
+// proxy's credential challenges, it must be called before navigating
+func interceptRequests(ctx context.Context, spec BlockingSpec, proxy *url.URL, logger *logrus.Entry) (*blockedRequests, error) {
+	blocked := &blockedRequests{}
+
+	authenticate := proxy != nil && proxy.User != nil
+	if spec.IsZero() && !authenticate {
+		return blocked, nil
+	}
+
+	// Credential challenges are only raised for intercepted requests, so a proxy with credentials intercepts everything
+	patterns := spec.patterns()
+	if authenticate {
+		patterns = []*fetch.RequestPattern{{URLPattern: "*"}}
+	}
+
+	chromedp.ListenTarget(ctx, func(ev any) {
+		switch ev := ev.(type) {
+		case *fetch.EventRequestPaused:
+			// Listeners must not block, the verdict is sent from another goroutine
+			go func() {
+				executor := cdp.WithExecutor(ctx, chromedp.FromContext(ctx).Target)
+
+				var err error
+				if spec.blocks(ev.ResourceType, ev.Request.URL) {
+					blocked.count.Add(1)
+
+					err = fetch.FailRequest(ev.RequestID, network.ErrorReasonBlockedByClient).Do(executor)
+				} else {
+					err = fetch.ContinueRequest(ev.RequestID).Do(executor)
+				}
+
+				if err != nil && ctx.Err() == nil {
+					logger.WithFields(logrus.Fields{
+						"intercepted_url": ev.Request.URL,
+						"error":           err,
+					}).Debug("Failed to answer intercepted request")
+				}
+			}()
+		case *fetch.EventAuthRequired:
+			go func() {
+				executor := cdp.WithExecutor(ctx, chromedp.FromContext(ctx).Target)
+
+				// Only the proxy gets its credentials, the site's own challenges are left alone
+				response := &fetch.AuthChallengeResponse{
+					Response: fetch.AuthChallengeResponseResponseDefault,
+				}
+
+				if ev.AuthChallenge != nil && ev.AuthChallenge.Source == fetch.AuthChallengeSourceProxy {
+					password, _ := proxy.User.Password()
+
+					response = &fetch.AuthChallengeResponse{
+						Response: fetch.AuthChallengeResponseResponseProvideCredentials,
+						Username: proxy.User.Username(),
+						Password: password,
+					}
+				}
+
+				err := fetch.ContinueWithAuth(ev.RequestID, response).Do(executor)
+				if err != nil && ctx.Err() == nil {
+					logger.WithFields(logrus.Fields{
+						"intercepted_url": ev.Request.URL,
+						"error":           err,
+					}).Debug("Failed to answer credential challenge")
+				}
+			}()
+		}
+	})
+
+	err := chromedp.Run(ctx, fetch.Enable().WithPatterns(patterns).WithHandleAuthRequests(authenticate))
+	if err != nil {
+		return nil, fmt.Errorf("failed to enable request interception: %w", err)
+	}
+
+	return blocked, nil
+}
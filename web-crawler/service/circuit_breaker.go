@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"web-crawler/breaker"
+
+	"github.com/sirupsen/logrus"
+)
+
+type GetAllCircuitBreakersParams struct{}
+
+type GetAllCircuitBreakersResult struct {
+	// Enabled is false when crawls are never skipped
+	Enabled  bool             `json:"enabled"`
+	Breakers []breaker.Status `json:"breakers"`
+}
+
+// GetAllCircuitBreakers returns the state of the circuit breaker of every crawled host
+func (service *Service) GetAllCircuitBreakers(ctx context.Context, params *GetAllCircuitBreakersParams) (*GetAllCircuitBreakersResult, error) {
+	const op = "[service] - Service.GetAllCircuitBreakers"
+
+	logger := service.logger.WithFields(logrus.Fields{
+		"[op]":   op,
+		"params": fmt.Sprintf("%+v", params),
+	})
+
+	logger.Info()
+
+	return &GetAllCircuitBreakersResult{
+		Enabled:  service.breakers != nil,
+		Breakers: service.breakers.Statuses(),
+	}, nil
+}
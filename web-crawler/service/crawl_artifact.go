@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"web-crawler/store/sqlc"
+
+	"github.com/chromedp/chromedp"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/sirupsen/logrus"
+)
+
+// Crawl artifact kinds
+const (
+	CrawlArtifactScreenshot = "screenshot"
+	CrawlArtifactDom        = "dom"
+)
+
+const (
+	// artifactCaptureTimeout bounds the capture, the crawl itself may have used up its own timeout
+	artifactCaptureTimeout = 15 * time.Second
+
+	// artifactScreenshotQuality is the JPEG quality of failure screenshots
+	artifactScreenshotQuality = 80
+)
+
+// ErrCrawlArtifactNotFound is returned when no artifact has the requested id
+var ErrCrawlArtifactNotFound = errors.New("crawl artifact not found")
+
+// captureFailureArtifacts stores a full page screenshot and the DOM of a failed browser crawl
+// Capturing is best effort, failures are logged and never hide the crawl error
+func (service *Service) captureFailureArtifacts(browserCtx context.Context, target *crawlTarget, attempt *crawlAttempt, failure error, logger *logrus.Entry) {
+	ctx, cancel := context.WithTimeout(browserCtx, artifactCaptureTimeout)
+	defer cancel()
+
+	var screenshot []byte
+	var dom string
+
+	err := chromedp.Run(ctx, chromedp.FullScreenshot(&screenshot, artifactScreenshotQuality))
+	if err != nil {
+		logger.WithError(err).Warn("Failed to take failure screenshot")
+	}
+
+	err = chromedp.Run(ctx, chromedp.OuterHTML("html", &dom, chromedp.ByQuery))
+	if err != nil {
+		logger.WithError(err).Warn("Failed to dump failure DOM")
+	}
+
+	artifacts := []struct {
+		kind        string
+		contentType string
+		content     []byte
+	}{
+		{CrawlArtifactScreenshot, "image/jpeg", screenshot},
+		{CrawlArtifactDom, "text/html; charset=utf-8", []byte(dom)},
+	}
+
+	for _, artifact := range artifacts {
+		if len(artifact.content) == 0 {
+			continue
+		}
+
+		// The crawl context may be cancelled already, the artifact is still worth keeping
+		id, err := service.store.CreateCrawlArtifact(context.WithoutCancel(browserCtx), sqlc.CreateCrawlArtifactParams{
+			SetupID:     target.SetupId,
+			Url:         target.Url,
+			Attempt:     int32(attempt.Number),
+			Kind:        artifact.kind,
+			ContentType: artifact.contentType,
+			Content:     artifact.content,
+			Error:       failure.Error(),
+			CreatedAt: pgtype.Timestamp{
+				Time:  time.Now(),
+				Valid: true,
+			},
+		})
+		if err != nil {
+			logger.WithFields(logrus.Fields{
+				"kind":  artifact.kind,
+				"error": err,
+			}).Warn("Failed to store crawl artifact")
+
+			continue
+		}
+
+		logger.WithFields(logrus.Fields{
+			"artifact_id": id,
+			"kind":        artifact.kind,
+			"size":        len(artifact.content),
+		}).Info("Stored crawl artifact")
+	}
+}
+
+type GetAllCrawlArtifactsParams struct {
+	Page int32
+	Size int32
+
+	// Optional filter, an empty setup id matches everything
+	SetupId string
+}
+
+type GetAllCrawlArtifactsResult struct {
+	Artifacts []sqlc.GetAllCrawlArtifactsRow `json:"artifacts"`
+	Page      int32                          `json:"page"`
+	Size      int32                          `json:"size"`
+	Pages     int32                          `json:"pages"`
+	Total     int64                          `json:"total"`
+}
+
+func (service *Service) GetAllCrawlArtifacts(ctx context.Context, params *GetAllCrawlArtifactsParams) (*GetAllCrawlArtifactsResult, error) {
+	const op = "[service] - Service.GetAllCrawlArtifacts"
+
+	logger := service.logger.WithFields(logrus.Fields{
+		"[op]":   op,
+		"params": fmt.Sprintf("%+v", params),
+	})
+
+	logger.Info()
+
+	// Initialize result
+	result := &GetAllCrawlArtifactsResult{}
+
+	// Calculate limit and offset from page and size
+	limit := params.Size
+	offset := (params.Page - 1) * params.Size
+
+	setupId := pgtype.Text{
+		String: params.SetupId,
+		Valid:  params.SetupId != "",
+	}
+
+	artifacts, err := service.store.GetAllCrawlArtifacts(ctx, sqlc.GetAllCrawlArtifactsParams{
+		SetupID: setupId,
+		Limit:   limit,
+		Offset:  offset,
+	})
+	if err != nil {
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
+
+	// Get total count
+	total, err := service.store.GetTotalCrawlArtifacts(ctx, setupId)
+	if err != nil {
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
+
+	// Calculate total pages
+	pages := (total + int64(params.Size) - 1) / int64(params.Size)
+
+	// Set result
+	result.Artifacts = artifacts
+	result.Page = params.Page
+	result.Size = params.Size
+	result.Pages = int32(pages)
+	result.Total = total
+
+	return result, nil
+}
+
+type GetCrawlArtifactParams struct {
+	ID int64
+}
+
+type GetCrawlArtifactResult struct {
+	Artifact sqlc.IbdwhCrawlArtifact
+}
+
+func (service *Service) GetCrawlArtifact(ctx context.Context, params *GetCrawlArtifactParams) (*GetCrawlArtifactResult, error) {
+	const op = "[service] - Service.GetCrawlArtifact"
+
+	logger := service.logger.WithFields(logrus.Fields{
+		"[op]":   op,
+		"params": fmt.Sprintf("%+v", params),
+	})
+
+	logger.Info()
+
+	artifact, err := service.store.GetCrawlArtifact(ctx, params.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCrawlArtifactNotFound
+	}
+
+	if err != nil {
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
+
+	return &GetCrawlArtifactResult{
+		Artifact: artifact,
+	}, nil
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"web-crawler/store/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// Crawl attempt outcomes
+const (
+	AttemptSuccess = "success"
+	AttemptFailure = "failure"
+	// AttemptSkipped is an attempt the breaker of the host refused, nothing was fetched
+	AttemptSkipped = "skipped"
+)
+
+// errorClassCircuitOpen is recorded for skipped attempts, it is no failure class and has no retry policy
+const errorClassCircuitOpen = "circuit_open"
+
+// recordAttempt stores the outcome of a crawl attempt along with the prices it extracted
+// Recording is best effort, failures are logged and never fail the crawl
+func (service *Service) recordAttempt(ctx context.Context, target *crawlTarget, attempt *crawlAttempt, startedAt time.Time, endedAt time.Time, failure error, logger *logrus.Entry) {
+	params := sqlc.CreateCrawlAttemptParams{
+		SetupID: target.SetupId,
+		Url:     target.Url,
+		Attempt: int32(attempt.Number),
+		Outcome: AttemptSuccess,
+		StartedAt: pgtype.Timestamp{
+			Time:  startedAt,
+			Valid: true,
+		},
+		EndedAt: pgtype.Timestamp{
+			Time:  endedAt,
+			Valid: true,
+		},
+	}
+
+	if len(attempt.Pages) > 0 {
+		params.FetchMode = textOf(attempt.Pages[len(attempt.Pages)-1].FetchMode)
+	}
+
+	if failure != nil {
+		params.Outcome = AttemptFailure
+		params.ErrorClass = textOf(string(attempt.ErrorClass))
+		params.Error = textOf(failure.Error())
+
+		// Prices found by an extraction that failed show what the page offered instead
+		var candidatesErr *candidatesError
+		if errors.As(failure, &candidatesErr) {
+			params.CandidatesRaw = candidatesErr.Candidates
+		}
+	}
+
+	if prices := attempt.Prices; prices != nil {
+		params.JualRaw = textOf(prices.Jual.Raw)
+		params.BeliRaw = textOf(prices.Beli.Raw)
+		params.Jual = decimal.NewNullDecimal(prices.Jual.Value)
+		params.Beli = decimal.NewNullDecimal(prices.Beli.Value)
+	}
+
+	// The job may have run out of time already, the attempt is still worth keeping
+	err := service.store.CreateCrawlAttempt(context.WithoutCancel(ctx), params)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to record crawl attempt")
+
+		return
+	}
+
+	logger.WithFields(logrus.Fields{
+		"outcome":                  params.Outcome,
+		"attempt_duration_seconds": endedAt.Sub(startedAt).Seconds(),
+	}).Debug("Recorded crawl attempt")
+}
+
+// recordSkip stores an attempt the breaker of the host refused, so that skips outlive the in-memory breaker
+// Recording is best effort, failures are logged and never fail the crawl
+func (service *Service) recordSkip(ctx context.Context, target *crawlTarget, attempt int, skippedAt time.Time, reason error, logger *logrus.Entry) {
+	params := sqlc.CreateCrawlAttemptParams{
+		SetupID:    target.SetupId,
+		Url:        target.Url,
+		Attempt:    int32(attempt),
+		Outcome:    AttemptSkipped,
+		ErrorClass: textOf(errorClassCircuitOpen),
+		Error:      textOf(reason.Error()),
+		StartedAt: pgtype.Timestamp{
+			Time:  skippedAt,
+			Valid: true,
+		},
+		EndedAt: pgtype.Timestamp{
+			Time:  skippedAt,
+			Valid: true,
+		},
+	}
+
+	err := service.store.CreateCrawlAttempt(context.WithoutCancel(ctx), params)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to record skipped crawl attempt")
+
+		return
+	}
+
+	logger.WithField("outcome", params.Outcome).Debug("Recorded crawl attempt")
+}
+
+// textOf returns a nullable text, NULL when empty
+func textOf(value string) pgtype.Text {
+	return pgtype.Text{
+		String: value,
+		Valid:  value != "",
+	}
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"web-crawler/browser"
+	"web-crawler/politeness"
+)
+
+// ErrorClass tells why a crawl attempt failed, every class has its own retry policy
+type ErrorClass string
+
+// Crawl error classes
+const (
+	// ErrorClassNetwork is a DNS, connection or TLS failure, a 5xx answer or an unreachable remote browser
+	ErrorClassNetwork ErrorClass = "network"
+	// ErrorClassTimeout is a fetch, navigation or wait running out of time
+	ErrorClassTimeout ErrorClass = "timeout"
+	// ErrorClassBlocked is a 401, 403, 407 or 429 answer, or a captcha or bot challenge page
+	ErrorClassBlocked ErrorClass = "blocked"
+	// ErrorClassHttp is any other unexpected HTTP status, such as a 404
+	ErrorClassHttp ErrorClass = "http"
+	// ErrorClassParse is a page whose prices could not be extracted
+	ErrorClassParse ErrorClass = "parse"
+	// ErrorClassSanity is a page whose extracted prices make no sense
+	ErrorClassSanity ErrorClass = "sanity"
+	// ErrorClassPoliteness is a fetch robots.txt or the host's rate limit did not allow, it is never retried
+	ErrorClassPoliteness ErrorClass = "politeness"
+	// ErrorClassUnknown is any other failure
+	ErrorClassUnknown ErrorClass = "unknown"
+)
+
+// errorClasses lists every class, in the order they are documented
+var errorClasses = []ErrorClass{
+	ErrorClassNetwork,
+	ErrorClassTimeout,
+	ErrorClassBlocked,
+	ErrorClassHttp,
+	ErrorClassParse,
+	ErrorClassSanity,
+	ErrorClassPoliteness,
+	ErrorClassUnknown,
+}
+
+// ErrCrawlSkipped is returned for crawls refused before their first attempt, because the breaker of their host is open
+var ErrCrawlSkipped = errors.New("crawl skipped")
+
+// blockedPageMarkers are only found in captcha and bot challenge pages, matched case-insensitively
+var blockedPageMarkers = []string{
+	"h-captcha",
+	"cf-challenge",
+	"cf-turnstile",
+	"attention required! | cloudflare",
+	"access denied</title>",
+	"please verify you are a human",
+}
+
+// challengeMarkers are found in challenge pages but also in normal pages embedding a captcha widget or a bot
+// script, so they only count on a page refused with a blocking status
+var challengeMarkers = []string{
+	"g-recaptcha",
+	"challenge-platform",
+}
+
+// CrawlError is a crawl failure along with its class
+type CrawlError struct {
+	Class ErrorClass
+	Err   error
+}
+
+func (err *CrawlError) Error() string {
+	return err.Err.Error()
+}
+
+func (err *CrawlError) Unwrap() error {
+	return err.Err
+}
+
+// ClassOf returns the class of a crawl error, empty for nil and unknown for errors that were never classified
+func ClassOf(err error) ErrorClass {
+	if err == nil {
+		return ""
+	}
+
+	var crawlErr *CrawlError
+	if errors.As(err, &crawlErr) {
+		return crawlErr.Class
+	}
+
+	return ErrorClassUnknown
+}
+
+// classifyError tells why a page failed from its error, its HTTP status and its content.
+// The status wins over the class given where the error was raised, a missing price on a
+// 404 is not a parse failure. The content is only looked at for errors that were never classified
+func classifyError(err error, statusCode int, content string) ErrorClass {
+	if err == nil {
+		return ""
+	}
+
+	if politeness.IsViolation(err) {
+		return ErrorClassPoliteness
+	}
+
+	// Checked before timeouts, a remote browser that does not answer is a network failure
+	if errors.Is(err, browser.ErrRemoteUnreachable) {
+		return ErrorClassNetwork
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) || strings.Contains(err.Error(), "ERR_TIMED_OUT") {
+		return ErrorClassTimeout
+	}
+
+	switch {
+	case statusCode == http.StatusUnauthorized, statusCode == http.StatusForbidden, statusCode == http.StatusProxyAuthRequired, statusCode == http.StatusTooManyRequests:
+		return ErrorClassBlocked
+	case statusCode == http.StatusServiceUnavailable && blockedPage(statusCode, content):
+		// Bot challenges are often served with a 503
+		return ErrorClassBlocked
+	case statusCode >= 500:
+		return ErrorClassNetwork
+	case statusCode >= 400:
+		return ErrorClassHttp
+	}
+
+	// A page that failed to parse or gave odd prices keeps its class, whatever widgets it embeds
+	var crawlErr *CrawlError
+	if errors.As(err, &crawlErr) {
+		return crawlErr.Class
+	}
+
+	if blockedPage(statusCode, content) {
+		return ErrorClassBlocked
+	}
+
+	// Browser navigations report network failures as "net::ERR_..." strings
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	if errors.As(err, &dnsErr) || errors.As(err, &opErr) || strings.Contains(err.Error(), "net::ERR_") {
+		return ErrorClassNetwork
+	}
+
+	return ErrorClassUnknown
+}
+
+// blockedPage reports whether the page is a captcha or bot challenge instead of the expected content
+func blockedPage(statusCode int, content string) bool {
+	if content == "" {
+		return false
+	}
+
+	content = strings.ToLower(content)
+	if containsMarker(content, blockedPageMarkers) {
+		return true
+	}
+
+	refused := statusCode == http.StatusForbidden || statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable
+
+	return refused && containsMarker(content, challengeMarkers)
+}
+
+func containsMarker(content string, markers []string) bool {
+	for _, marker := range markers {
+		if strings.Contains(content, marker) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// reportToBreaker tells the breaker of the target's host how a failed attempt went, only failures pointing at the host count against it
+func (service *Service) reportToBreaker(target *crawlTarget, class ErrorClass, err error) {
+	switch class {
+	case ErrorClassNetwork, ErrorClassTimeout, ErrorClassBlocked:
+		service.breakers.Failure(target.Url, err)
+	case ErrorClassPoliteness, ErrorClassUnknown:
+		service.breakers.Release(target.Url)
+	default:
+		// The host answered, even though the page could not be used
+		service.breakers.Success(target.Url)
+	}
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	const recaptchaPage = `<html><body><form><div class="g-recaptcha" data-sitekey="key"></div></form>` +
+		`<script src="/cdn-cgi/challenge-platform/scripts/jsd/main.js"></script></body></html>`
+	const challengePage = `<html><head><title>Attention Required! | Cloudflare</title></head></html>`
+
+	parseErr := &CrawlError{Class: ErrorClassParse, Err: errors.New("jual price not found")}
+	sanityErr := &CrawlError{Class: ErrorClassSanity, Err: errors.New("jual price below beli price")}
+
+	tests := []struct {
+		name       string
+		err        error
+		statusCode int
+		content    string
+		want       ErrorClass
+	}{
+		{
+			name:       "parse failure on a 200 page with a recaptcha widget",
+			err:        parseErr,
+			statusCode: 200,
+			content:    recaptchaPage,
+			want:       ErrorClassParse,
+		},
+		{
+			name:       "sanity failure on a 200 page with a recaptcha widget",
+			err:        fmt.Errorf("failed to extract prices: %w", sanityErr),
+			statusCode: 200,
+			content:    recaptchaPage,
+			want:       ErrorClassSanity,
+		},
+		{
+			name:       "parse failure on a challenge page keeps its class",
+			err:        parseErr,
+			statusCode: 200,
+			content:    challengePage,
+			want:       ErrorClassParse,
+		},
+		{
+			name:       "unclassified failure on a challenge page",
+			err:        errors.New("readiness selector not found"),
+			statusCode: 200,
+			content:    challengePage,
+			want:       ErrorClassBlocked,
+		},
+		{
+			name:       "unclassified failure on a 200 page with a recaptcha widget",
+			err:        errors.New("readiness selector not found"),
+			statusCode: 200,
+			content:    recaptchaPage,
+			want:       ErrorClassUnknown,
+		},
+		{
+			name:       "challenge served with a 503",
+			err:        errors.New("unexpected status code: 503"),
+			statusCode: 503,
+			content:    recaptchaPage,
+			want:       ErrorClassBlocked,
+		},
+		{
+			name:       "503 without a challenge",
+			err:        errors.New("unexpected status code: 503"),
+			statusCode: 503,
+			content:    "<html><body>Service Unavailable</body></html>",
+			want:       ErrorClassNetwork,
+		},
+		{
+			name:       "status wins over the class",
+			err:        parseErr,
+			statusCode: 404,
+			content:    "<html><body>Not Found</body></html>",
+			want:       ErrorClassHttp,
+		},
+		{
+			name:       "forbidden",
+			err:        errors.New("unexpected status code: 403"),
+			statusCode: 403,
+			want:       ErrorClassBlocked,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := classifyError(test.err, test.statusCode, test.content); got != test.want {
+				t.Errorf("classifyError() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
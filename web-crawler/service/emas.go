@@ -3,16 +3,14 @@ package service
 import (
 	"context"
 	"fmt"
-	"math/big"
-	"regexp"
-	"strconv"
-	"strings"
+	"math/rand"
 	"time"
 
+	"web-crawler/politeness"
 	"web-crawler/store/sqlc"
 
-	"github.com/chromedp/chromedp"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
 )
 
@@ -77,14 +75,29 @@ func (service *Service) GetAllEmas(ctx context.Context, params *GetAllEmasParams
 	return result, nil
 }
 
+// EmasSetupId is the Pegadaian setup whose observations make up the daily ibdwh.emas rows
+const EmasSetupId = "hourly_gold_price"
+
 type CreateEmasParams struct {
-	Url       string
-	CreatedAt time.Time
-	Retry     RetryConfig
+	SetupId    string
+	Url        string
+	CreatedAt  time.Time
+	Retry      RetryConfig
+	Extraction ExtractionSpec
+	FetchMode  string
+	Readiness  ReadinessSpec
+	Blocking   BlockingSpec
+	Rotation   RotationSpec
+	Steps      []BrowserStep
+	Session    SessionSpec
+	Emulation  EmulationSpec
+	Timeouts   TimeoutSpec
 }
 
 type CreateEmasResult struct {
-	ID string
+	// ID is the daily emas row refreshed by the observation, empty for setups other than EmasSetupId
+	ID            string
+	ObservationID int64
 }
 
 func (service *Service) CreateEmas(ctx context.Context, params *CreateEmasParams) (*CreateEmasResult, error) {
@@ -101,289 +114,335 @@ func (service *Service) CreateEmas(ctx context.Context, params *CreateEmasParams
 	// Initialize result
 	result := &CreateEmasResult{}
 
-	// Crawl gold prices from website with retry
-	jual, beli, err := service.crawlGoldPricesWithRetry(ctx, params.Url, params.Retry, logger)
+	// Fall back to the Pegadaian extraction spec when none is configured
+	spec := params.Extraction
+	if spec.IsZero() {
+		spec = DefaultExtractionSpec()
+	}
+
+	err := spec.Validate()
 	if err != nil {
-		err = fmt.Errorf("failed to crawl gold prices: %w", err)
+		err = fmt.Errorf("invalid extraction spec: %w", err)
 
 		logger.WithError(err).Error()
 
 		return nil, err
 	}
 
-	// Generate date-based emas_id (YYYY-MM-DD format)
-	emasID := params.CreatedAt.Format("2006-01-02")
+	err = params.Retry.Validate()
+	if err != nil {
+		err = fmt.Errorf("invalid retry config: %w", err)
 
-	// Create or update emas (UPSERT)
-	emas, err := service.store.CreateEmas(ctx, sqlc.CreateEmasParams{
-		EmasID: emasID,
-		Jual: pgtype.Numeric{
-			Int:   big.NewInt(int64(jual)),
-			Valid: true,
-		},
-		Beli: pgtype.Numeric{
-			Int:   big.NewInt(int64(beli)),
-			Valid: true,
-		},
-		CreatedAt: pgtype.Timestamp{
-			Time:  params.CreatedAt,
-			Valid: true,
-		},
-	})
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
+
+	err = ValidateFetchMode(params.FetchMode)
 	if err != nil {
 		logger.WithError(err).Error()
 
 		return nil, err
 	}
 
-	// Set result
-	result.ID = emas.EmasID
+	err = params.Readiness.Validate()
+	if err != nil {
+		err = fmt.Errorf("invalid readiness spec: %w", err)
 
-	return result, nil
-}
+		logger.WithError(err).Error()
 
-// crawlGoldPrices fetches gold prices from the specified website using headless browser
-// This method handles JavaScript-rendered content properly
-func (service *Service) crawlGoldPrices(ctx context.Context, url string) (float64, float64, error) {
-	const op = "[service] - Service.crawlGoldPrices"
+		return nil, err
+	}
 
-	logger := service.logger.WithFields(logrus.Fields{
-		"[op]": op,
-	})
+	err = params.Blocking.Validate()
+	if err != nil {
+		err = fmt.Errorf("invalid blocking spec: %w", err)
 
-	logger.WithFields(logrus.Fields{
-		"message": "Starting gold price crawling using headless browser",
-	}).Info()
+		logger.WithError(err).Error()
 
-	// Create a new browser context with timeout
-	ctx, cancel := chromedp.NewContext(ctx, chromedp.WithLogf(logger.Printf))
-	defer cancel()
+		return nil, err
+	}
+
+	err = params.Rotation.Validate(params.FetchMode)
+	if err != nil {
+		err = fmt.Errorf("invalid rotation spec: %w", err)
+
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
 
-	// Set a reasonable timeout for the entire operation
-	ctx, timeoutCancel := context.WithTimeout(ctx, 60*time.Second)
-	defer timeoutCancel()
-
-	var pageContent string
-	var priceElements []string
-
-	err := chromedp.Run(ctx,
-		// Navigate to the gold price page
-		chromedp.Navigate(url),
-
-		// Wait for the page to load
-		chromedp.WaitVisible("body", chromedp.ByQuery),
-
-		// Wait a bit more for JavaScript to load dynamic content
-		chromedp.Sleep(5*time.Second),
-
-		// Get the full page content for debugging
-		chromedp.InnerHTML("html", &pageContent, chromedp.ByQuery),
-
-		// Try to find elements containing gold price patterns
-		chromedp.Evaluate(`
-			(function() {
-				// Look for any text containing price patterns
-				const elements = Array.from(document.querySelectorAll('*'));
-				const pricePatterns = ['0,01 gr', '0.01 gr', '/ 0,01 gr', '/ 0.01 gr'];
-				const foundElements = [];
-				
-				elements.forEach(el => {
-					const text = el.textContent || '';
-					pricePatterns.forEach(pattern => {
-						if (text.includes(pattern) && text.length < 200) {
-							foundElements.push(text.trim());
-						}
-					});
-				});
-				
-				// Remove duplicates and return
-				return [...new Set(foundElements)];
-			})()
-		`, &priceElements),
-	)
+	err = ValidateSteps(params.Steps)
+	if err != nil {
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
 
+	err = params.Session.Validate()
 	if err != nil {
-		err = fmt.Errorf("failed to scrape website with headless browser: %w", err)
+		err = fmt.Errorf("invalid session spec: %w", err)
 
 		logger.WithError(err).Error()
 
-		return 0, 0, err
+		return nil, err
 	}
 
-	logger.WithFields(logrus.Fields{
-		"found":              len(priceElements),
-		"potential_elements": fmt.Sprintf("%+v", priceElements),
-	}).Info()
+	err = params.Emulation.Validate()
+	if err != nil {
+		err = fmt.Errorf("invalid emulation spec: %w", err)
+
+		logger.WithError(err).Error()
 
-	// Extract prices from the found elements
-	var prices []float64
-
-	// Look for price patterns in the extracted elements
-	// The format appears to be: "Rp 18.500 / 0,01 gr" with periods as thousands separators
-	patterns := []string{
-		"/ 0,01 gr",
-		"/ 0.01 gr",
-		"/0,01 gr",
-		"/0.01 gr",
-		"0,01 gr",
-		"0.01 gr",
+		return nil, err
 	}
 
-	for _, element := range priceElements {
-		// Clean up Unicode characters that might interfere with regex matching
-		cleanElement := strings.ReplaceAll(element, "\u00a0", " ") // Replace non-breaking space with regular space
-		cleanElement = strings.ReplaceAll(cleanElement, "  ", " ") // Replace multiple spaces with single space
-		cleanElement = strings.TrimSpace(cleanElement)
-
-		for _, pattern := range patterns {
-			if strings.Contains(cleanElement, pattern) {
-				// Extract price using regex - handle both comma and period as decimal/thousand separators
-				// Look for patterns like "Rp 18.500 / 0,01 gr" or "18.500 / 0,01 gr"
-				re := regexp.MustCompile(`(?i)(?:rp\s*)?([0-9]+(?:\.[0-9]{3})*(?:,[0-9]+)?)\s*` + regexp.QuoteMeta(pattern))
-				matches := re.FindStringSubmatch(cleanElement)
-
-				if len(matches) > 1 {
-					priceStr := matches[1]
-					// Handle Indonesian number format: periods for thousands, comma for decimal
-					// Convert "18.500" to "18500" and "18.500,50" to "18500.50"
-					parts := strings.Split(priceStr, ",")
-					integerPart := strings.ReplaceAll(parts[0], ".", "")
-
-					var finalPriceStr string
-					if len(parts) > 1 {
-						// Has decimal part
-						finalPriceStr = integerPart + "." + parts[1]
-					} else {
-						// No decimal part
-						finalPriceStr = integerPart
-					}
-
-					if price, err := strconv.ParseFloat(finalPriceStr, 64); err == nil {
-						// Multiply by 100 since prices are per 0.01 gr to get price per gram
-						finalPrice := price * 100
-
-						prices = append(prices, finalPrice)
-					} else {
-						logger.WithFields(logrus.Fields{
-							"raw_text":        element,
-							"clean_text":      cleanElement,
-							"price_str":       priceStr,
-							"final_price_str": finalPriceStr,
-							"error":           err,
-						}).Warn("Failed to parse extracted price")
-					}
-				}
-			}
-		}
+	err = params.Timeouts.Validate()
+	if err != nil {
+		err = fmt.Errorf("invalid timeout spec: %w", err)
+
+		logger.WithError(err).Error()
+
+		return nil, err
 	}
 
-	// If we couldn't find prices in structured elements, try a broader search
-	if len(prices) < 2 {
-		logger.Warn("Could not find prices in structured elements, trying broader search")
-
-		// Search the entire page content for price patterns
-		// Clean up Unicode characters in page content
-		cleanPageContent := strings.ReplaceAll(pageContent, "\u00a0", " ")
-
-		for _, pattern := range patterns {
-			re := regexp.MustCompile(`(?i)(?:rp\s*)?([0-9]+(?:\.[0-9]{3})*(?:,[0-9]+)?)\s*` + regexp.QuoteMeta(pattern))
-			matches := re.FindAllStringSubmatch(cleanPageContent, -1)
-
-			for _, match := range matches {
-				if len(match) > 1 {
-					priceStr := match[1]
-					// Handle Indonesian number format: periods for thousands, comma for decimal
-					parts := strings.Split(priceStr, ",")
-					integerPart := strings.ReplaceAll(parts[0], ".", "")
-
-					var finalPriceStr string
-					if len(parts) > 1 {
-						// Has decimal part
-						finalPriceStr = integerPart + "." + parts[1]
-					} else {
-						// No decimal part
-						finalPriceStr = integerPart
-					}
-
-					if price, err := strconv.ParseFloat(finalPriceStr, 64); err == nil {
-						// Multiply by 100 since prices are per 0.01 gr to get price per gram
-						finalPrice := price * 100
-
-						prices = append(prices, finalPrice)
-					} else {
-						logger.WithFields(logrus.Fields{
-							"pattern":           pattern,
-							"matched_price_str": priceStr,
-							"parsed_price":      price,
-							"final_price_str":   finalPriceStr,
-							"error":             err,
-						}).Warn("Failed to parse extracted price")
-					}
-				}
-			}
-		}
+	// Every attempt, retry wait and write of the job must fit in its deadline
+	ctx, cancel := withPhaseTimeout(ctx, PhaseJob, params.Timeouts.job())
+	defer cancel()
+
+	// Steps and sessions can only run in a browser
+	if (len(params.Steps) > 0 || !params.Session.IsZero()) && params.FetchMode == FetchModeStatic {
+		err = fmt.Errorf("browser steps and sessions need the %q or %q fetch mode", FetchModeBrowser, FetchModeAuto)
+
+		logger.WithError(err).Error()
+
+		return nil, err
 	}
 
-	if len(prices) < 2 {
-		err = fmt.Errorf("could not find both gold prices on the website, found %d prices", len(prices))
+	// Crawl gold prices from website with retry
+	crawled, err := service.crawlGoldPricesWithRetry(ctx, &crawlTarget{
+		SetupId:    params.SetupId,
+		Url:        params.Url,
+		FetchMode:  params.FetchMode,
+		Extraction: spec,
+		Readiness:  params.Readiness,
+		Blocking:   params.Blocking,
+		Rotation:   params.Rotation,
+		Steps:      params.Steps,
+		Session:    params.Session,
+		Emulation:  params.Emulation,
+		Timeouts:   params.Timeouts,
+	}, params.Retry, logger)
+	if err != nil {
+		err = fmt.Errorf("failed to crawl gold prices: %w", err)
 
 		logger.WithError(err).Error()
 
-		// Log a sample of the page content for debugging
-		contentSample := pageContent
-		if len(contentSample) > 1000 {
-			contentSample = contentSample[:1000] + "..."
+		return nil, err
+	}
+
+	// Store the observation and refresh the daily rollups atomically
+	err = service.store.WithTx(ctx, func(q *sqlc.Queries) error {
+		stored, err := storeObservation(ctx, q, &observation{
+			SetupId:    params.SetupId,
+			Url:        params.Url,
+			ObservedAt: params.CreatedAt,
+			CapturedAt: crawled.CapturedAt,
+			Jual:       crawled.Jual,
+			Beli:       crawled.Beli,
+			Product:    spec.product(),
+			Products:   crawled.Products,
+		})
+		if err != nil {
+			return err
 		}
 
-		logger.WithField("page_sample", contentSample).Debug("Page content sample")
+		// Set result
+		result.ID = stored.ID
+		result.ObservationID = stored.ObservationID
+
+		return nil
+	})
+	if err != nil {
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// observation is a set of prices extracted from one page, ready to be stored
+type observation struct {
+	SetupId    string
+	Url        string
+	ObservedAt time.Time
+	Jual       extractedPrice
+	Beli       extractedPrice
+
+	// CapturedAt is the fetch time of the page, it ties the observation to the page snapshot a replay corrects it from
+	CapturedAt time.Time
+
+	// Product is the product the per gram jual and beli prices belong to
+	Product  string
+	Products []extractedProductPrice
+}
 
-		return 0, 0, err
+// storeObservation writes the observation and its product prices, then refreshes the daily rollups of its setup.
+// It is meant to run in a transaction, the result ID is empty for setups other than EmasSetupId
+func storeObservation(ctx context.Context, q sqlc.Querier, observed *observation) (*CreateEmasResult, error) {
+	stored := &CreateEmasResult{}
+
+	created, err := q.CreatePriceObservation(ctx, sqlc.CreatePriceObservationParams{
+		SetupID: observed.SetupId,
+		Url:     observed.Url,
+		Jual:    observed.Jual.Value,
+		Beli:    observed.Beli.Value,
+		JualLabel: pgtype.Text{
+			String: observed.Jual.Label,
+			Valid:  observed.Jual.Label != "",
+		},
+		BeliLabel: pgtype.Text{
+			String: observed.Beli.Label,
+			Valid:  observed.Beli.Label != "",
+		},
+		ObservedAt: pgtype.Timestamp{
+			Time:  observed.ObservedAt,
+			Valid: true,
+		},
+		CapturedAt: pgtype.Timestamp{
+			Time:  observed.CapturedAt,
+			Valid: !observed.CapturedAt.IsZero(),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create price observation: %w", err)
 	}
 
-	// Remove duplicate prices
-	uniquePrices := make(map[float64]bool)
-	var distinctPrices []float64
-	for _, price := range prices {
-		if !uniquePrices[price] {
-			uniquePrices[price] = true
-			distinctPrices = append(distinctPrices, price)
+	stored.ObservationID = created.ObservationID
+
+	// The headline prices are per gram of the product
+	products := append([]extractedProductPrice{{
+		Product:     observed.Product,
+		WeightGrams: decimal.NewFromInt(1),
+		Jual:        observed.Jual.Value,
+		Beli:        decimal.NewNullDecimal(observed.Beli.Value),
+	}}, observed.Products...)
+
+	for _, product := range products {
+		_, err = q.CreateProductPrice(ctx, sqlc.CreateProductPriceParams{
+			ObservationID: created.ObservationID,
+			Product:       product.Product,
+			WeightGrams:   product.WeightGrams,
+			Jual:          product.Jual,
+			Beli:          product.Beli,
+			ObservedAt:    created.ObservedAt,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s price for %s gr: %w", product.Product, product.WeightGrams, err)
 		}
 	}
 
-	if len(distinctPrices) < 2 {
-		err = fmt.Errorf("could not find two distinct gold prices on the website, found %d distinct prices", len(distinctPrices))
+	// The daily emas row only follows the Pegadaian setup, other dealers have their own prices
+	if observed.SetupId == EmasSetupId {
+		emas, err := rollupEmas(ctx, q, observed.ObservedAt)
+		if err != nil {
+			return nil, err
+		}
 
-		logger.WithError(err).Error()
+		stored.ID = emas.EmasID
+	}
+
+	_, err = rollupEmasOhlc(ctx, q, observed.SetupId, observed.ObservedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return stored, nil
+}
+
+// rollupEmas upserts the daily emas row with the latest price observation of the day made by the Pegadaian setup
+func rollupEmas(ctx context.Context, q sqlc.Querier, date time.Time) (sqlc.IbdwhEma, error) {
+	emas, err := q.RollupEmas(ctx, sqlc.RollupEmasParams{
+		SetupID: EmasSetupId,
+		Day: pgtype.Date{
+			Time:  time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC),
+			Valid: true,
+		},
+	})
+	if err != nil {
+		return emas, fmt.Errorf("failed to rollup emas for %s: %w", date.Format("2006-01-02"), err)
+	}
+
+	return emas, nil
+}
+
+// crawlGoldPrices fetches gold prices from the target website using its fetch mode
+func (service *Service) crawlGoldPrices(ctx context.Context, target *crawlTarget, attempt *crawlAttempt) (*crawlResult, error) {
+	const op = "[service] - Service.crawlGoldPrices"
+
+	logger := service.logger.WithFields(logrus.Fields{
+		"[op]":       op,
+		"fetch_mode": target.FetchMode,
+	})
+
+	var result *crawlResult
+	var err error
+
+	switch target.FetchMode {
+	case FetchModeStatic:
+		result, err = service.crawlStatic(ctx, target, attempt, logger)
+	case FetchModeAuto:
+		// A static fetch cannot run the setup's steps nor keep its session, so the browser is used right away
+		if target.needsBrowser() {
+			result, err = service.crawlBrowser(ctx, target, attempt, logger)
+
+			break
+		}
+
+		result, err = service.crawlStatic(ctx, target, attempt, logger)
+		if err == nil || politeness.IsViolation(err) {
+			break
+		}
+
+		logger.WithFields(logrus.Fields{
+			"message": "Static crawl failed, falling back to headless browser",
+			"error":   err.Error(),
+		}).Warn()
 
-		return 0, 0, err
+		result, err = service.crawlBrowser(ctx, target, attempt, logger)
+	default:
+		result, err = service.crawlBrowser(ctx, target, attempt, logger)
 	}
 
-	// Sort distinct prices to determine which is higher (Jual) and which is lower (Beli)
-	var jual, beli float64
-	if distinctPrices[0] > distinctPrices[1] {
-		jual = distinctPrices[0] // Higher price is selling price
-		beli = distinctPrices[1] // Lower price is buying price
-	} else {
-		jual = distinctPrices[1] // Higher price is selling price
-		beli = distinctPrices[0] // Lower price is buying price
+	if err != nil {
+		return nil, err
 	}
 
 	logger.WithFields(logrus.Fields{
-		"jual_price":       jual,
-		"beli_price":       beli,
-		"price_difference": jual - beli,
+		"used_fetch_mode":  result.FetchMode,
+		"jual_price":       result.Jual.Value.String(),
+		"jual_label":       result.Jual.Label,
+		"beli_price":       result.Beli.Value.String(),
+		"beli_label":       result.Beli.Label,
+		"price_difference": result.Jual.Value.Sub(result.Beli.Value).String(),
+		"product_prices":   len(result.Products),
 	}).Info()
 
-	return jual, beli, nil
+	return result, nil
 }
 
-// crawlGoldPricesWithRetry implements retry logic with exponential backoff
-func (service *Service) crawlGoldPricesWithRetry(ctx context.Context, url string, retryConfig RetryConfig, logger *logrus.Entry) (float64, float64, error) {
+// crawlGoldPricesWithRetry implements retry logic with the backoff strategy of the retry config, honoring Retry-After hints
+func (service *Service) crawlGoldPricesWithRetry(ctx context.Context, target *crawlTarget, retryConfig RetryConfig, logger *logrus.Entry) (*crawlResult, error) {
 	const op = "[service] - Service.crawlGoldPricesWithRetry"
 
-	var jual, beli float64
+	var result *crawlResult
 	var lastErr error
 
+	rotation := service.rotation(target)
+
+	backoff := newBackoff(rand.NewSource(time.Now().UnixNano()))
+
 	for attempt := 1; attempt <= retryConfig.MaxAttempts; attempt++ {
 		logger := logger.WithFields(logrus.Fields{
 			"[op]":         op,
@@ -391,44 +450,128 @@ func (service *Service) crawlGoldPricesWithRetry(ctx context.Context, url string
 			"max_attempts": retryConfig.MaxAttempts,
 		})
 
+		// Hosts that keep failing are left alone until their breaker lets a probe through
+		err := service.breakers.Allow(target.Url)
+		if err != nil {
+			service.recordSkip(ctx, target, attempt, time.Now(), err, logger)
+
+			if attempt == 1 {
+				err = fmt.Errorf("%w: %w", ErrCrawlSkipped, err)
+
+				logger.WithError(err).Warn()
+			} else {
+				err = fmt.Errorf("crawl stopped after attempt %d: %w, last error: %w", attempt-1, err, lastErr)
+
+				logger.WithError(err).Error()
+			}
+
+			return nil, err
+		}
+
+		// Every attempt may go out through another proxy and user agent
+		identity := rotation.pick(time.Now(), logger)
+
 		logger.WithFields(logrus.Fields{
-			"message": "Starting scraping attempt",
+			"message":    "Starting scraping attempt",
+			"proxy":      identity.proxyName(),
+			"user_agent": identity.userAgent(),
 		}).Info()
 
 		// Try to crawl gold prices
-		jual, beli, lastErr = service.crawlGoldPrices(ctx, url)
+		current := &crawlAttempt{Number: attempt, Identity: identity}
+
+		startedAt := time.Now()
+
+		attemptCtx, attemptCancel := withPhaseTimeout(ctx, PhaseAttempt, target.Timeouts.attempt())
+		result, lastErr = service.crawlGoldPrices(attemptCtx, target, current)
+		lastErr = phaseTimeout(lastErr, attemptCtx)
+		attemptCancel()
+
+		endedAt := time.Now()
+
+		rotation.report(identity, current, logger)
+
+		// Keep the raw pages of the attempt, whatever its outcome
+		service.archivePages(ctx, target, current, logger)
+
 		if lastErr == nil {
+			service.breakers.Success(target.Url)
+
+			service.recordAttempt(ctx, target, current, startedAt, endedAt, nil, logger)
+
 			logger.WithFields(logrus.Fields{
-				"message": "Successfully scraped gold prices",
-				"jual":    jual,
-				"beli":    beli,
+				"message":    "Successfully scraped gold prices",
+				"fetch_mode": result.FetchMode,
+				"jual":       result.Jual.Value.String(),
+				"beli":       result.Beli.Value.String(),
 			}).Info()
 
-			return jual, beli, nil
+			return result, nil
 		}
 
+		// The class decides how the failure is retried
+		current.ErrorClass = current.classify(lastErr)
+		lastErr = &CrawlError{Class: current.ErrorClass, Err: lastErr}
+
+		service.reportToBreaker(target, current.ErrorClass, lastErr)
+
+		service.recordAttempt(ctx, target, current, startedAt, endedAt, lastErr, logger)
+
 		logger.WithFields(logrus.Fields{
-			"message": "Scraping attempt failed",
-			"error":   lastErr,
+			"message":     "Scraping attempt failed",
+			"error":       lastErr,
+			"error_class": current.ErrorClass,
 		}).Warn()
 
+		maxAttempts, classRetryConfig := retryConfig.policy(current.ErrorClass)
+
 		// If this is the last attempt, don't wait
 		if attempt >= retryConfig.MaxAttempts {
 			break
 		}
 
-		// Calculate delay with exponential backoff
-		delay := service.calculateBackoffDelay(attempt, retryConfig)
+		// A job out of time has no attempt left
+		if ctx.Err() != nil {
+			err := fmt.Errorf("crawl stopped after attempt %d: %w", attempt, phaseTimeout(lastErr, ctx))
+
+			logger.WithError(err).Error()
+
+			return nil, err
+		}
+
+		// Failures that would happen again stop the crawl before using up every attempt
+		if attempt >= maxAttempts {
+			err := fmt.Errorf("crawl stopped after attempt %d, %s errors are tried at most %d times: %w", attempt, current.ErrorClass, maxAttempts, lastErr)
+
+			logger.WithError(err).Error()
+
+			return nil, err
+		}
+
+		// Calculate delay with the backoff strategy
+		delay := backoff.delay(attempt, classRetryConfig)
+
+		// The host knows best when it can be tried again
+		retryAfter := current.retryAfter()
+		delay, ok := retryConfig.retryDelay(delay, retryAfter)
+		if !ok {
+			err := fmt.Errorf("crawl stopped after attempt %d, the host asked to retry after %s: %w", attempt, retryAfter, lastErr)
+
+			logger.WithError(err).Error()
+
+			return nil, err
+		}
 
 		logger.WithFields(logrus.Fields{
-			"message":       "Waiting before next retry attempt",
-			"delay_seconds": delay.Seconds(),
+			"message":             "Waiting before next retry attempt",
+			"delay_seconds":       delay.Seconds(),
+			"retry_after_seconds": retryAfter.Seconds(),
 		}).Info()
 
 		// Wait before next attempt
 		select {
 		case <-ctx.Done():
-			return 0, 0, fmt.Errorf("context cancelled during retry wait: %w", ctx.Err())
+			return nil, fmt.Errorf("context cancelled during retry wait: %w", phaseTimeout(ctx.Err(), ctx))
 		case <-time.After(delay):
 			// Continue to next attempt
 		}
@@ -438,5 +581,5 @@ func (service *Service) crawlGoldPricesWithRetry(ctx context.Context, url string
 
 	logger.WithError(err).Error()
 
-	return 0, 0, err
+	return nil, err
 }
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"web-crawler/store/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/sirupsen/logrus"
+)
+
+type GetAllEmasOhlcParams struct {
+	Page int32
+	Size int32
+
+	// SetupId only lists the rows of the setup when set
+	SetupId string
+}
+
+type GetAllEmasOhlcResult struct {
+	Ohlc  []sqlc.IbdwhEmasOhlc `json:"ohlc"`
+	Page  int32                `json:"page"`
+	Size  int32                `json:"size"`
+	Pages int32                `json:"pages"`
+	Total int64                `json:"total"`
+}
+
+func (service *Service) GetAllEmasOhlc(ctx context.Context, params *GetAllEmasOhlcParams) (*GetAllEmasOhlcResult, error) {
+	const op = "[service] - Service.GetAllEmasOhlc"
+
+	logger := service.logger.WithFields(logrus.Fields{
+		"[op]":   op,
+		"params": fmt.Sprintf("%+v", params),
+	})
+
+	logger.Info()
+
+	// Initialize result
+	result := &GetAllEmasOhlcResult{}
+
+	// Calculate limit and offset from page and size
+	limit := params.Size
+	offset := (params.Page - 1) * params.Size
+
+	setupId := pgtype.Text{
+		String: params.SetupId,
+		Valid:  params.SetupId != "",
+	}
+
+	ohlc, err := service.store.GetAllEmasOhlc(ctx, sqlc.GetAllEmasOhlcParams{
+		SetupID: setupId,
+		Limit:   limit,
+		Offset:  offset,
+	})
+	if err != nil {
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
+
+	// Get total count
+	total, err := service.store.GetTotalEmasOhlc(ctx, setupId)
+	if err != nil {
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
+
+	// Calculate total pages
+	pages := (total + int64(params.Size) - 1) / int64(params.Size)
+
+	// Set result
+	result.Ohlc = ohlc
+	result.Page = params.Page
+	result.Size = params.Size
+	result.Pages = int32(pages)
+	result.Total = total
+
+	return result, nil
+}
+
+// rollupEmasOhlc upserts the setup's daily open/high/low/close row with its price observations of the day
+func rollupEmasOhlc(ctx context.Context, q sqlc.Querier, setupId string, date time.Time) (sqlc.IbdwhEmasOhlc, error) {
+	ohlc, err := q.RollupEmasOhlc(ctx, sqlc.RollupEmasOhlcParams{
+		SetupID: setupId,
+		Day: pgtype.Date{
+			Time:  time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC),
+			Valid: true,
+		},
+	})
+	if err != nil {
+		return ohlc, fmt.Errorf("failed to rollup emas ohlc of %s for %s: %w", setupId, date.Format("2006-01-02"), err)
+	}
+
+	return ohlc, nil
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	cdpbrowser "github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/chromedp"
+	"github.com/chromedp/chromedp/device"
+)
+
+// Device profiles
+const (
+	// DeviceDesktop renders the page on a 1920x1080 desktop screen
+	DeviceDesktop = "desktop"
+	// DeviceMobile renders the page on a touch phone screen with a mobile user agent
+	DeviceMobile = "mobile"
+)
+
+// languageTagPattern matches a language range of an Accept-Language header, such as "id-ID" or "*"
+var languageTagPattern = regexp.MustCompile(`^([A-Za-z]{1,8}(-[A-Za-z0-9]{1,8})*|\*)$`)
+
+// GeolocationSpec is the position reported to the page, Accuracy is in meters
+type GeolocationSpec struct {
+	Latitude  float64
+	Longitude float64
+	Accuracy  float64
+}
+
+// EmulationSpec pins the browser settings a page may adapt its layout to.
+//
+// Device picks the desktop or mobile profile, Width, Height and Scale override
+// its screen. AcceptLanguage is sent with every request and its first language
+// becomes the page locale. Settings left empty keep the browser defaults.
+type EmulationSpec struct {
+	Timezone       string
+	AcceptLanguage string
+	Geolocation    *GeolocationSpec
+	Device         string
+	Width          int64
+	Height         int64
+	Scale          float64
+}
+
+// Validate checks the device profile, the screen size, the time zone, the languages and the position
+func (spec EmulationSpec) Validate() error {
+	switch spec.Device {
+	case "", DeviceDesktop, DeviceMobile:
+	default:
+		return fmt.Errorf("unsupported device: %q", spec.Device)
+	}
+
+	if spec.Width < 0 || spec.Height < 0 || spec.Scale < 0 {
+		return fmt.Errorf("width, height and scale must not be negative")
+	}
+
+	if (spec.Width == 0) != (spec.Height == 0) {
+		return fmt.Errorf("width and height must be set together")
+	}
+
+	if spec.Timezone != "" {
+		if _, err := time.LoadLocation(spec.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone: %w", err)
+		}
+	}
+
+	if spec.AcceptLanguage != "" {
+		for _, language := range strings.Split(spec.AcceptLanguage, ",") {
+			tag, _, _ := strings.Cut(language, ";")
+			if !languageTagPattern.MatchString(strings.TrimSpace(tag)) {
+				return fmt.Errorf("invalid accept language: %q", spec.AcceptLanguage)
+			}
+		}
+	}
+
+	if geolocation := spec.Geolocation; geolocation != nil {
+		if geolocation.Latitude < -90 || geolocation.Latitude > 90 || geolocation.Longitude < -180 || geolocation.Longitude > 180 {
+			return fmt.Errorf("invalid geolocation: %v, %v", geolocation.Latitude, geolocation.Longitude)
+		}
+
+		if geolocation.Accuracy < 0 {
+			return fmt.Errorf("geolocation accuracy must not be negative")
+		}
+	}
+
+	return nil
+}
+
+// screen returns the device profile with the spec's size and scale applied, nil when the browser default is kept
+func (spec EmulationSpec) screen() *device.Info {
+	var screen *device.Info
+
+	switch spec.Device {
+	case DeviceDesktop:
+		screen = &device.Info{Width: 1920, Height: 1080, Scale: 1}
+	case DeviceMobile:
+		pixel := device.Pixel5.Device()
+		screen = &pixel
+	default:
+		if spec.Width == 0 {
+			return nil
+		}
+
+		screen = &device.Info{Scale: 1}
+	}
+
+	if spec.Width > 0 {
+		screen.Width = spec.Width
+		screen.Height = spec.Height
+	}
+
+	if spec.Scale > 0 {
+		screen.Scale = spec.Scale
+	}
+
+	return screen
+}
+
+// locale returns the first language of the Accept-Language header in the ICU form the browser expects, such as "id_ID"
+func (spec EmulationSpec) locale() string {
+	language, _, _ := strings.Cut(spec.AcceptLanguage, ",")
+	language, _, _ = strings.Cut(language, ";")
+	language = strings.TrimSpace(language)
+
+	if language == "*" {
+		return ""
+	}
+
+	return strings.ReplaceAll(language, "-", "_")
+}
+
+// emulate applies the setup's emulation settings and the attempt's user agent to the tab, it must be called before navigating.
+// The user agent of the attempt wins over the one of the mobile profile
+func emulate(ctx context.Context, spec EmulationSpec, userAgent string, pageUrl string) error {
+	screen := spec.screen()
+
+	if userAgent == "" && screen != nil && screen.Mobile {
+		userAgent = screen.UserAgent
+	}
+
+	if userAgent != "" || spec.AcceptLanguage != "" {
+		// The header can only be overridden along with the user agent, the browser's own is kept when none is set.
+		// It is read through chromedp.Run, which attaches the tab to its target on first use
+		if userAgent == "" {
+			err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+				_, _, _, browserUserAgent, _, err := cdpbrowser.GetVersion().Do(ctx)
+				userAgent = browserUserAgent
+
+				return err
+			}))
+			if err != nil {
+				return fmt.Errorf("failed to read browser user agent: %w", err)
+			}
+		}
+
+		err := chromedp.Run(ctx, emulation.SetUserAgentOverride(userAgent).WithAcceptLanguage(spec.AcceptLanguage))
+		if err != nil {
+			return fmt.Errorf("failed to set user agent: %w", err)
+		}
+	}
+
+	if locale := spec.locale(); locale != "" {
+		err := chromedp.Run(ctx, emulation.SetLocaleOverride().WithLocale(locale))
+		if err != nil {
+			return fmt.Errorf("failed to emulate locale: %w", err)
+		}
+	}
+
+	if spec.Timezone != "" {
+		err := chromedp.Run(ctx, emulation.SetTimezoneOverride(spec.Timezone))
+		if err != nil {
+			return fmt.Errorf("failed to emulate timezone: %w", err)
+		}
+	}
+
+	if screen != nil {
+		err := chromedp.Run(ctx,
+			emulation.SetDeviceMetricsOverride(screen.Width, screen.Height, screen.Scale, screen.Mobile),
+			emulation.SetTouchEmulationEnabled(screen.Touch),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to emulate device: %w", err)
+		}
+	}
+
+	if geolocation := spec.Geolocation; geolocation != nil {
+		err := emulateGeolocation(ctx, *geolocation, pageUrl)
+		if err != nil {
+			return fmt.Errorf("failed to emulate geolocation: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// emulateGeolocation reports the position to the page and lets its origin read it without a prompt
+func emulateGeolocation(ctx context.Context, geolocation GeolocationSpec, pageUrl string) error {
+	parsed, err := url.Parse(pageUrl)
+	if err != nil {
+		return err
+	}
+
+	// Permissions belong to the browser context, so they are granted through the browser once the tab is attached,
+	// the browser context of the tab is only known by then
+	grant := chromedp.ActionFunc(func(ctx context.Context) error {
+		tab := chromedp.FromContext(ctx)
+
+		params := cdpbrowser.GrantPermissions([]cdpbrowser.PermissionType{cdpbrowser.PermissionTypeGeolocation}).
+			WithOrigin(parsed.Scheme + "://" + parsed.Host)
+		if tab.BrowserContextID != "" {
+			params = params.WithBrowserContextID(tab.BrowserContextID)
+		}
+
+		return params.Do(cdp.WithExecutor(ctx, tab.Browser))
+	})
+
+	return chromedp.Run(ctx, grant, emulation.SetGeolocationOverride().
+		WithLatitude(geolocation.Latitude).
+		WithLongitude(geolocation.Longitude).
+		WithAccuracy(geolocation.Accuracy))
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/chromedp/chromedp"
+)
+
+func TestEmulateFreshTab(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    EmulationSpec
+		wantErr string
+	}{
+		{
+			name:    "accept language without user agent",
+			spec:    EmulationSpec{AcceptLanguage: "id-ID,id;q=0.9"},
+			wantErr: "failed to read browser user agent",
+		},
+		{
+			name:    "geolocation",
+			spec:    EmulationSpec{Geolocation: &GeolocationSpec{Latitude: -6.2, Longitude: 106.8, Accuracy: 100}},
+			wantErr: "failed to emulate geolocation",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The tab is never attached, nothing listens on the remote browser address
+			allocatorCtx, cancelAllocator := chromedp.NewRemoteAllocator(context.Background(), "ws://127.0.0.1:1/devtools/browser/none")
+			defer cancelAllocator()
+
+			ctx, cancel := chromedp.NewContext(allocatorCtx)
+			defer cancel()
+
+			err := emulate(ctx, test.spec, "", "https://www.pegadaian.co.id/harga")
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("emulate() error = %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,517 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"web-crawler/util/number"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
+	"github.com/chromedp/chromedp"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/html"
+)
+
+// defaultLocale is used to parse prices when the spec has no locale
+const defaultLocale = "id-ID"
+
+// Extraction rule types
+const (
+	ExtractionRuleCSS   = "css"
+	ExtractionRuleXPath = "xpath"
+	ExtractionRuleRegex = "regex"
+	ExtractionRuleJS    = "js"
+)
+
+// pegadaianPricePattern matches prices like "Rp 18.500 / 0,01 gr" and captures the number
+const pegadaianPricePattern = `(?i)(?:rp\s*)?([0-9]+(?:\.[0-9]{3})*(?:,[0-9]+)?)\s*/?\s*0[,.]01 gr`
+
+// ExtractionRule describes one way of locating a value on a page.
+//
+// Expression is interpreted according to Type: a CSS selector, an XPath
+// expression, a regular expression run over the page HTML (group 1 is used
+// when present) or a JavaScript snippet returning a string or an array of
+// strings. Pattern is an optional regular expression applied to every matched
+// text to isolate the number (group 1 is used when present).
+type ExtractionRule struct {
+	Type       string
+	Expression string
+	Pattern    string
+}
+
+// ExtractionSpec declares how gold prices are extracted from a page.
+//
+// When Labels are set, each price is tied to the DOM label next to it. Otherwise
+// Jual and Beli are tried rule by rule until one yields a price. When they are
+// not set either, Prices is used: the first two distinct prices found are
+// compared and the higher one is taken as jual. Every price is multiplied by
+// UnitMultiplier (e.g. 100 to turn a per 0.01 gram price into a per gram one).
+// Prices are parsed using the number format of Locale (e.g. "id-ID").
+//
+// The jual and beli prices are the per gram prices of Product. Products lists
+// other products whose prices are extracted for each of their weights.
+type ExtractionSpec struct {
+	Labels         ExtractionLabels
+	Jual           []ExtractionRule
+	Beli           []ExtractionRule
+	Prices         []ExtractionRule
+	UnitMultiplier decimal.Decimal
+	Locale         string
+	Product        string
+	Products       []ExtractionProduct
+}
+
+// extractedPrice is a price found on a page along with the text and label it came from
+type extractedPrice struct {
+	Value decimal.Decimal
+	Raw   string
+	Label string
+}
+
+// candidatesError is an extraction failure that found prices it could not use, their texts are kept in the attempt history
+type candidatesError struct {
+	Candidates []string
+	Err        error
+}
+
+func (err *candidatesError) Error() string {
+	return err.Err.Error()
+}
+
+func (err *candidatesError) Unwrap() error {
+	return err.Err
+}
+
+// withCandidates keeps the texts of the prices found along with the extraction failure, the error is unchanged when none were found
+func withCandidates(err error, found ...[]extractedPrice) error {
+	var candidates []string
+	for _, prices := range found {
+		for _, price := range prices {
+			candidates = append(candidates, price.Raw)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return err
+	}
+
+	return &candidatesError{Candidates: candidates, Err: err}
+}
+
+// extractedPrices holds the jual and beli prices found on a page
+type extractedPrices struct {
+	Jual extractedPrice
+	Beli extractedPrice
+}
+
+// DefaultExtractionSpec returns the spec for the Pegadaian gold price page
+func DefaultExtractionSpec() ExtractionSpec {
+	return ExtractionSpec{
+		Labels: ExtractionLabels{
+			Jual:    []string{"Harga Jual"},
+			Beli:    []string{"Harga Beli", "Buyback"},
+			Pattern: pegadaianPricePattern,
+		},
+		UnitMultiplier: decimal.NewFromInt(100),
+		Locale:         defaultLocale,
+		Product:        defaultProduct,
+		Products:       DefaultExtractionProducts(),
+	}
+}
+
+// IsZero reports whether nothing of the spec is set.
+// A spec setting only a locale, multiplier or products is not zero, Validate then asks for its rules instead of it being replaced by the default spec
+func (spec ExtractionSpec) IsZero() bool {
+	return spec.Labels.IsZero() && len(spec.Jual) == 0 && len(spec.Beli) == 0 && len(spec.Prices) == 0 &&
+		spec.UnitMultiplier.IsZero() && spec.Locale == "" && spec.Product == "" && len(spec.Products) == 0
+}
+
+// Validate checks that every label and rule of the spec can be evaluated
+func (spec ExtractionSpec) Validate() error {
+	if !spec.Labels.IsZero() {
+		if err := spec.Labels.validate(); err != nil {
+			return fmt.Errorf("invalid labels: %w", err)
+		}
+	} else if len(spec.Prices) == 0 && (len(spec.Jual) == 0 || len(spec.Beli) == 0) {
+		return fmt.Errorf("extraction spec needs either labels, both jual and beli rules or prices rules")
+	}
+
+	if spec.Locale != "" {
+		if _, err := number.LookupLocale(spec.Locale); err != nil {
+			return err
+		}
+	}
+
+	fields := []struct {
+		name  string
+		rules []ExtractionRule
+	}{
+		{"jual", spec.Jual},
+		{"beli", spec.Beli},
+		{"prices", spec.Prices},
+	}
+
+	for _, field := range fields {
+		for i, rule := range field.rules {
+			if err := rule.validate(); err != nil {
+				return fmt.Errorf("invalid %s rule #%d: %w", field.name, i+1, err)
+			}
+		}
+	}
+
+	names := map[string]bool{}
+	for i, product := range spec.Products {
+		if err := product.validate(); err != nil {
+			return fmt.Errorf("invalid product #%d: %w", i+1, err)
+		}
+
+		if names[product.Name] {
+			return fmt.Errorf("duplicate product: %q", product.Name)
+		}
+
+		names[product.Name] = true
+	}
+
+	return nil
+}
+
+// locale returns the number format prices are parsed with
+func (spec ExtractionSpec) locale() (number.Locale, error) {
+	if spec.Locale == "" {
+		return number.LookupLocale(defaultLocale)
+	}
+
+	return number.LookupLocale(spec.Locale)
+}
+
+// product returns the name of the product the jual and beli prices belong to
+func (spec ExtractionSpec) product() string {
+	if spec.Product == "" {
+		return defaultProduct
+	}
+
+	return spec.Product
+}
+
+func (rule ExtractionRule) validate() error {
+	if rule.Expression == "" {
+		return fmt.Errorf("expression is required")
+	}
+
+	switch rule.Type {
+	case ExtractionRuleCSS:
+		if _, err := cascadia.Compile(rule.Expression); err != nil {
+			return fmt.Errorf("invalid expression: %w", err)
+		}
+	case ExtractionRuleXPath:
+		if _, err := xpath.Compile(rule.Expression); err != nil {
+			return fmt.Errorf("invalid expression: %w", err)
+		}
+	case ExtractionRuleRegex:
+		if _, err := regexp.Compile(rule.Expression); err != nil {
+			return fmt.Errorf("invalid expression: %w", err)
+		}
+	case ExtractionRuleJS:
+		// Only the browser can tell whether a snippet is valid
+	default:
+		return fmt.Errorf("unsupported rule type: %q", rule.Type)
+	}
+
+	if rule.Pattern != "" {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// extractionPage is a loaded page that extraction rules are evaluated against
+type extractionPage struct {
+	html string
+	doc  *html.Node
+
+	// evaluate runs a JavaScript snippet in the page, nil when no browser is attached
+	evaluate func(expression string) ([]string, error)
+}
+
+func newExtractionPage(content string, evaluate func(expression string) ([]string, error)) (*extractionPage, error) {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse page content: %w", err)
+	}
+
+	return &extractionPage{
+		html:     content,
+		doc:      doc,
+		evaluate: evaluate,
+	}, nil
+}
+
+// browserEvaluator returns an evaluate function running snippets in the given chromedp context
+func browserEvaluator(ctx context.Context) func(expression string) ([]string, error) {
+	return func(expression string) ([]string, error) {
+		var value any
+
+		err := chromedp.Run(ctx, chromedp.Evaluate(expression, &value))
+		if err != nil {
+			return nil, err
+		}
+
+		switch value := value.(type) {
+		case nil:
+			return nil, nil
+		case string:
+			return []string{value}, nil
+		case []any:
+			texts := make([]string, 0, len(value))
+			for _, item := range value {
+				if item != nil {
+					texts = append(texts, fmt.Sprint(item))
+				}
+			}
+
+			return texts, nil
+		default:
+			return []string{fmt.Sprint(value)}, nil
+		}
+	}
+}
+
+// matchRule returns the raw texts matched by a rule
+func (page *extractionPage) matchRule(rule ExtractionRule) ([]string, error) {
+	var texts []string
+
+	switch rule.Type {
+	case ExtractionRuleCSS:
+		doc := goquery.NewDocumentFromNode(page.doc)
+		doc.Find(rule.Expression).Each(func(_ int, selection *goquery.Selection) {
+			texts = append(texts, selection.Text())
+		})
+	case ExtractionRuleXPath:
+		nodes, err := htmlquery.QueryAll(page.doc, rule.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid xpath expression: %w", err)
+		}
+
+		for _, node := range nodes {
+			texts = append(texts, htmlquery.InnerText(node))
+		}
+	case ExtractionRuleRegex:
+		re, err := regexp.Compile(rule.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex expression: %w", err)
+		}
+
+		texts = findAllCaptures(re, cleanText(page.html))
+	case ExtractionRuleJS:
+		if page.evaluate == nil {
+			return nil, fmt.Errorf("js rules need a browser")
+		}
+
+		var err error
+		texts, err = page.evaluate(rule.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate js rule: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported rule type: %q", rule.Type)
+	}
+
+	if rule.Pattern == "" {
+		return texts, nil
+	}
+
+	re, err := regexp.Compile(rule.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule pattern: %w", err)
+	}
+
+	var matches []string
+	for _, text := range texts {
+		matches = append(matches, findAllCaptures(re, cleanText(text))...)
+	}
+
+	return matches, nil
+}
+
+// extractPrices returns every price matched by the rules, stopping at the first rule yielding at least min prices
+func (service *Service) extractPrices(page *extractionPage, rules []ExtractionRule, locale number.Locale, multiplier decimal.Decimal, min int, logger *logrus.Entry) []extractedPrice {
+	var prices []extractedPrice
+
+	for i, rule := range rules {
+		logger := logger.WithFields(logrus.Fields{
+			"rule": i + 1,
+			"type": rule.Type,
+		})
+
+		matches, err := page.matchRule(rule)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to evaluate extraction rule")
+
+			continue
+		}
+
+		logger.WithFields(logrus.Fields{
+			"found":   len(matches),
+			"matches": fmt.Sprintf("%+v", matches),
+		}).Info()
+
+		for _, match := range matches {
+			price, err := number.Parse(match, locale)
+			if err != nil {
+				logger.WithFields(logrus.Fields{
+					"matched_price_str": match,
+					"error":             err,
+				}).Warn("Failed to parse extracted price")
+
+				continue
+			}
+
+			prices = append(prices, extractedPrice{
+				Value: price.Mul(multiplier),
+				Raw:   match,
+			})
+		}
+
+		if len(prices) >= min {
+			break
+		}
+
+		logger.Warn("Extraction rule did not yield enough prices, trying next rule")
+	}
+
+	return prices
+}
+
+// extractGoldPrices runs the extraction spec against a page and returns the jual and beli prices once they pass the sanity checks.
+// Prices failing the checks are returned along with the error, so that the attempt history keeps them
+func (service *Service) extractGoldPrices(page *extractionPage, spec ExtractionSpec, logger *logrus.Entry) (*extractedPrices, error) {
+	prices, err := service.findGoldPrices(page, spec, logger)
+	if err != nil {
+		return nil, &CrawlError{Class: ErrorClassParse, Err: err}
+	}
+
+	err = checkGoldPrices(prices)
+	if err != nil {
+		return prices, &CrawlError{Class: ErrorClassSanity, Err: err}
+	}
+
+	return prices, nil
+}
+
+// checkGoldPrices rejects prices no gold dealer would show, they point at the wrong page elements
+func checkGoldPrices(prices *extractedPrices) error {
+	if !prices.Jual.Value.IsPositive() || !prices.Beli.Value.IsPositive() {
+		return fmt.Errorf("implausible gold prices: jual %s and beli %s must be positive", prices.Jual.Value, prices.Beli.Value)
+	}
+
+	if prices.Beli.Value.GreaterThan(prices.Jual.Value) {
+		return fmt.Errorf("implausible gold prices: beli %s is above jual %s", prices.Beli.Value, prices.Jual.Value)
+	}
+
+	return nil
+}
+
+// findGoldPrices looks for the jual and beli prices following the extraction spec
+func (service *Service) findGoldPrices(page *extractionPage, spec ExtractionSpec, logger *logrus.Entry) (*extractedPrices, error) {
+	multiplier := spec.UnitMultiplier
+	if multiplier.IsZero() {
+		multiplier = decimal.NewFromInt(1)
+	}
+
+	locale, err := spec.locale()
+	if err != nil {
+		return nil, err
+	}
+
+	// Prices tied to their labels
+	if !spec.Labels.IsZero() {
+		return service.extractLabelledPrices(page, spec.Labels, locale, multiplier, logger)
+	}
+
+	// Dedicated rules for each price
+	if len(spec.Jual) > 0 && len(spec.Beli) > 0 {
+		jualPrices := service.extractPrices(page, spec.Jual, locale, multiplier, 1, logger.WithField("field", "jual"))
+		if len(jualPrices) == 0 {
+			return nil, fmt.Errorf("could not find jual price on the website")
+		}
+
+		beliPrices := service.extractPrices(page, spec.Beli, locale, multiplier, 1, logger.WithField("field", "beli"))
+		if len(beliPrices) == 0 {
+			return nil, withCandidates(fmt.Errorf("could not find beli price on the website"), jualPrices)
+		}
+
+		return &extractedPrices{
+			Jual: jualPrices[0],
+			Beli: beliPrices[0],
+		}, nil
+	}
+
+	// Candidate prices where the higher one is jual
+	prices := service.extractPrices(page, spec.Prices, locale, multiplier, 2, logger.WithField("field", "prices"))
+	if len(prices) < 2 {
+		return nil, withCandidates(fmt.Errorf("could not find both gold prices on the website, found %d prices", len(prices)), prices)
+	}
+
+	// Remove duplicate prices
+	var distinctPrices []extractedPrice
+	for _, price := range prices {
+		duplicate := false
+		for _, distinctPrice := range distinctPrices {
+			if price.Value.Equal(distinctPrice.Value) {
+				duplicate = true
+
+				break
+			}
+		}
+
+		if !duplicate {
+			distinctPrices = append(distinctPrices, price)
+		}
+	}
+
+	if len(distinctPrices) < 2 {
+		return nil, withCandidates(fmt.Errorf("could not find two distinct gold prices on the website, found %d distinct prices", len(distinctPrices)), prices)
+	}
+
+	// Compare distinct prices to determine which is higher (Jual) and which is lower (Beli)
+	if distinctPrices[0].Value.GreaterThan(distinctPrices[1].Value) {
+		return &extractedPrices{
+			Jual: distinctPrices[0],
+			Beli: distinctPrices[1],
+		}, nil
+	}
+
+	return &extractedPrices{
+		Jual: distinctPrices[1],
+		Beli: distinctPrices[0],
+	}, nil
+}
+
+// findAllCaptures returns group 1 of every match, or the whole match when the regex has no groups
+func findAllCaptures(re *regexp.Regexp, text string) []string {
+	var captures []string
+
+	for _, match := range re.FindAllStringSubmatch(text, -1) {
+		if len(match) > 1 {
+			captures = append(captures, match[1])
+		} else {
+			captures = append(captures, match[0])
+		}
+	}
+
+	return captures
+}
+
+// cleanText normalizes Unicode spacing that might interfere with regex matching
+// Non-breaking spaces, newlines and runs of spaces all become a single space
+func cleanText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
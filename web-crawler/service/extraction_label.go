@@ -0,0 +1,374 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"web-crawler/util/number"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/html"
+)
+
+const (
+	// defaultLabelPricePattern matches prices written with a rupiah prefix and captures the number
+	defaultLabelPricePattern = `(?i)(?:rp\.?|idr)\s*([0-9]+(?:[.,][0-9]+)*)`
+
+	// defaultLabelMaxDepth is how many ancestors of a label may be climbed to reach its price
+	defaultLabelMaxDepth = 4
+)
+
+// ExtractionLabels ties each price to the DOM label in front of it.
+//
+// A price belongs to the nearest label preceding it in document order, as long
+// as both sit under the same ancestor at most MaxDepth levels above the label.
+// Labels of both fields sharing that ancestor make the price ambiguous.
+// Pattern matches prices in the page text (group 1 is used when present).
+type ExtractionLabels struct {
+	Jual     []string
+	Beli     []string
+	Pattern  string
+	MaxDepth int
+}
+
+// IsZero reports whether no labels are configured
+func (labels ExtractionLabels) IsZero() bool {
+	return len(labels.Jual) == 0 && len(labels.Beli) == 0
+}
+
+func (labels ExtractionLabels) validate() error {
+	if len(labels.Jual) == 0 || len(labels.Beli) == 0 {
+		return fmt.Errorf("both jual and beli labels are required")
+	}
+
+	for _, label := range append(append([]string{}, labels.Jual...), labels.Beli...) {
+		if strings.TrimSpace(label) == "" {
+			return fmt.Errorf("labels must not be empty")
+		}
+	}
+
+	if labels.Pattern != "" {
+		if _, err := regexp.Compile(labels.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// labelEvent is a label or a price found in the flattened page text
+type labelEvent struct {
+	start int
+	end   int
+
+	// field is "jual", "beli" or a product name for labels and empty for prices
+	field string
+	label string
+	raw   string
+}
+
+// flatText is the text of a page with every text node concatenated in document order
+type flatText struct {
+	text     string
+	starts   []int
+	elements []*html.Node
+}
+
+func newFlatText(doc *html.Node) *flatText {
+	flat := &flatText{}
+	var builder strings.Builder
+
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			switch node.Data {
+			case "script", "style", "noscript", "template":
+				return
+			}
+		}
+
+		if node.Type == html.TextNode {
+			text := cleanText(node.Data)
+			if text != "" {
+				flat.starts = append(flat.starts, builder.Len())
+				flat.elements = append(flat.elements, node.Parent)
+
+				builder.WriteString(text)
+				builder.WriteString(" ")
+			}
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+
+	walk(doc)
+
+	flat.text = builder.String()
+
+	return flat
+}
+
+// elementAt returns the element holding the text at the given offset
+func (flat *flatText) elementAt(offset int) *html.Node {
+	i := sort.Search(len(flat.starts), func(i int) bool { return flat.starts[i] > offset }) - 1
+	if i < 0 {
+		return nil
+	}
+
+	return flat.elements[i]
+}
+
+// commonAncestor returns the closest element holding both nodes and how many levels above the first node it sits
+func commonAncestor(a *html.Node, b *html.Node) (*html.Node, int) {
+	depth := 0
+	for ancestor := a; ancestor != nil; ancestor = ancestor.Parent {
+		if contains(ancestor, b) {
+			return ancestor, depth
+		}
+
+		depth++
+	}
+
+	return nil, depth
+}
+
+// contains reports whether node is the ancestor itself or one of its descendants
+func contains(ancestor *html.Node, node *html.Node) bool {
+	for ; node != nil; node = node.Parent {
+		if node == ancestor {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findLabels returns every non-overlapping label occurrence standing as whole words, preferring the longest label at a position
+func findLabels(text string, labels map[string][]string) []labelEvent {
+	var events []labelEvent
+
+	fields := make([]string, 0, len(labels))
+	for field := range labels {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		for _, label := range labels[field] {
+			re := regexp.MustCompile(`(?i)` + regexp.QuoteMeta(cleanText(label)))
+			for _, loc := range re.FindAllStringIndex(text, -1) {
+				// "UBS" must not match inside "Subscribe"
+				if !wholeWords(text, loc[0], loc[1]) {
+					continue
+				}
+
+				events = append(events, labelEvent{
+					start: loc[0],
+					end:   loc[1],
+					field: field,
+					label: label,
+				})
+			}
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].start != events[j].start {
+			return events[i].start < events[j].start
+		}
+
+		if events[i].end != events[j].end {
+			return events[i].end > events[j].end
+		}
+
+		return events[i].field < events[j].field
+	})
+
+	var result []labelEvent
+	for _, event := range events {
+		if len(result) > 0 && event.start < result[len(result)-1].end {
+			continue
+		}
+
+		result = append(result, event)
+	}
+
+	return result
+}
+
+// wholeWords reports whether the text between start and end is not glued to the words around it.
+// A label starting or ending with punctuation, such as "(Jual)", needs no boundary on that side
+func wholeWords(text string, start int, end int) bool {
+	first, _ := utf8.DecodeRuneInString(text[start:end])
+	if isWordRune(first) && start > 0 {
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		if isWordRune(before) {
+			return false
+		}
+	}
+
+	last, _ := utf8.DecodeLastRuneInString(text[start:end])
+	if isWordRune(last) && end < len(text) {
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if isWordRune(after) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isWordRune reports whether the rune is part of a word in any script
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// extractLabelledPrices binds every price on the page to its label and returns the jual and beli prices
+func (service *Service) extractLabelledPrices(page *extractionPage, labels ExtractionLabels, locale number.Locale, multiplier decimal.Decimal, logger *logrus.Entry) (*extractedPrices, error) {
+	logger = logger.WithField("field", "labels")
+
+	pattern := labels.Pattern
+	if pattern == "" {
+		pattern = defaultLabelPricePattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label pattern: %w", err)
+	}
+
+	maxDepth := labels.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = defaultLabelMaxDepth
+	}
+
+	flat := newFlatText(page.doc)
+
+	events := findLabels(flat.text, map[string][]string{
+		"jual": labels.Jual,
+		"beli": labels.Beli,
+	})
+
+	for _, loc := range re.FindAllStringSubmatchIndex(flat.text, -1) {
+		raw := flat.text[loc[0]:loc[1]]
+		if len(loc) > 3 && loc[2] >= 0 {
+			raw = flat.text[loc[2]:loc[3]]
+		}
+
+		events = append(events, labelEvent{
+			start: loc[0],
+			end:   loc[1],
+			raw:   raw,
+		})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].start < events[j].start })
+
+	found := map[string][]extractedPrice{}
+
+	for i, event := range events {
+		if event.field != "" {
+			continue
+		}
+
+		// Nearest label preceding the price
+		j := i - 1
+		for j >= 0 && events[j].field == "" {
+			j--
+		}
+
+		if j < 0 {
+			continue
+		}
+
+		priceElement := flat.elementAt(event.start)
+		labelElement := flat.elementAt(events[j].start)
+		if labelElement == nil || priceElement == nil {
+			continue
+		}
+
+		container, depth := commonAncestor(labelElement, priceElement)
+		if container == nil || depth > maxDepth {
+			continue
+		}
+
+		// Labels of both fields sharing the price's container cannot be told apart
+		for k := j - 1; k >= 0 && events[k].field != ""; k-- {
+			if events[k].field == events[j].field {
+				continue
+			}
+
+			other, _ := commonAncestor(flat.elementAt(events[k].start), priceElement)
+			if other != nil && contains(container, other) {
+				err := fmt.Errorf("ambiguous labels: price %q follows both %q and %q", event.raw, events[k].label, events[j].label)
+
+				return nil, withCandidates(err, found["jual"], found["beli"], []extractedPrice{{Raw: event.raw}})
+			}
+		}
+
+		value, err := number.Parse(event.raw, locale)
+		if err != nil {
+			logger.WithFields(logrus.Fields{
+				"matched_price_str": event.raw,
+				"label":             events[j].label,
+				"error":             err,
+			}).Warn("Failed to parse labelled price")
+
+			continue
+		}
+
+		found[events[j].field] = append(found[events[j].field], extractedPrice{
+			Value: value.Mul(multiplier),
+			Raw:   event.raw,
+			Label: events[j].label,
+		})
+	}
+
+	logger.WithFields(logrus.Fields{
+		"jual_matches": fmt.Sprintf("%+v", found["jual"]),
+		"beli_matches": fmt.Sprintf("%+v", found["beli"]),
+	}).Info()
+
+	jual, err := singlePrice("jual", labels.Jual, found["jual"])
+	if err != nil {
+		return nil, withCandidates(err, found["jual"], found["beli"])
+	}
+
+	beli, err := singlePrice("beli", labels.Beli, found["beli"])
+	if err != nil {
+		return nil, withCandidates(err, found["jual"], found["beli"])
+	}
+
+	return &extractedPrices{
+		Jual: jual,
+		Beli: beli,
+	}, nil
+}
+
+// singlePrice returns the price of a field, failing when its labels point at several different prices
+func singlePrice(field string, labels []string, prices []extractedPrice) (extractedPrice, error) {
+	if len(prices) == 0 {
+		return extractedPrice{}, fmt.Errorf("could not find %s price labelled %q on the website", field, labels)
+	}
+
+	var conflicts []string
+	for _, price := range prices {
+		if !price.Value.Equal(prices[0].Value) {
+			conflicts = append(conflicts, fmt.Sprintf("%s (%q)", price.Value, price.Label))
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return extractedPrice{}, fmt.Errorf("ambiguous labels: %s price %s (%q) conflicts with %s", field, prices[0].Value, prices[0].Label, strings.Join(conflicts, ", "))
+	}
+
+	return prices[0], nil
+}
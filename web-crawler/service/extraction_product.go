@@ -0,0 +1,227 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"web-crawler/util/number"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultProduct names the product of the headline jual and beli prices
+	defaultProduct = "Tabungan Emas"
+
+	// defaultProductPattern matches rows like "0,5 gr Rp 1.034.000" with an optional second buyback price
+	// Only separators may sit between the weight and its prices, so "/ 0,01 gr" unit notes are not rows
+	defaultProductPattern = `(?i)(?P<weight>[0-9]+(?:[.,][0-9]+)?)\s*(?:gr|gram)\b[^0-9a-z]*?(?:rp\.?|idr)\s*(?P<jual>[0-9]+(?:[.,][0-9]+)*)(?:[^0-9a-z]*?(?:rp\.?|idr)\s*(?P<beli>[0-9]+(?:[.,][0-9]+)*))?`
+)
+
+// ExtractionProduct declares how the prices of one product are extracted for each of its weights.
+//
+// The product section is either the text matched by Rules or, when no rules are
+// set, the page text running from one of Labels to the next label of any
+// product. Pattern is run over the section and must have "weight" and "jual"
+// named groups, plus an optional "beli" group. Prices are stored as listed for
+// the weight, without the spec's UnitMultiplier.
+type ExtractionProduct struct {
+	Name    string
+	Labels  []string
+	Rules   []ExtractionRule
+	Pattern string
+}
+
+// extractedProductPrice is the price of a product for one weight
+type extractedProductPrice struct {
+	Product     string
+	WeightGrams decimal.Decimal
+	Jual        decimal.Decimal
+	Beli        decimal.NullDecimal
+}
+
+// DefaultExtractionProducts returns the gold bar products listed on the Pegadaian gold price page
+func DefaultExtractionProducts() []ExtractionProduct {
+	return []ExtractionProduct{
+		{Name: "Antam", Labels: []string{"Antam"}},
+		{Name: "UBS", Labels: []string{"UBS"}},
+		{Name: "Galeri24", Labels: []string{"Galeri24", "Galeri 24"}},
+	}
+}
+
+func (product ExtractionProduct) validate() error {
+	if strings.TrimSpace(product.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	if len(product.Labels) == 0 && len(product.Rules) == 0 {
+		return fmt.Errorf("either labels or rules are required")
+	}
+
+	for _, label := range product.Labels {
+		if strings.TrimSpace(label) == "" {
+			return fmt.Errorf("labels must not be empty")
+		}
+	}
+
+	for i, rule := range product.Rules {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("invalid rule #%d: %w", i+1, err)
+		}
+	}
+
+	if product.Pattern != "" {
+		re, err := regexp.Compile(product.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+
+		if re.SubexpIndex("weight") < 0 || re.SubexpIndex("jual") < 0 {
+			return fmt.Errorf("pattern needs weight and jual named groups")
+		}
+	}
+
+	return nil
+}
+
+// extractProductPrices returns the price of every product and weight found on the page
+// Products that cannot be found are logged and skipped, they never fail the crawl
+func (service *Service) extractProductPrices(page *extractionPage, spec ExtractionSpec, logger *logrus.Entry) []extractedProductPrice {
+	if len(spec.Products) == 0 {
+		return nil
+	}
+
+	locale, err := spec.locale()
+	if err != nil {
+		logger.WithError(err).Warn("Failed to extract product prices")
+
+		return nil
+	}
+
+	// Sections delimited by product labels share the flattened page text
+	labels := map[string][]string{}
+	for _, product := range spec.Products {
+		labels[product.Name] = product.Labels
+	}
+
+	flat := newFlatText(page.doc)
+	events := findLabels(flat.text, labels)
+
+	var prices []extractedProductPrice
+	seen := map[string]bool{}
+
+	for _, product := range spec.Products {
+		logger := logger.WithField("product", product.Name)
+
+		var sections []string
+		if len(product.Rules) > 0 {
+			for i, rule := range product.Rules {
+				texts, err := page.matchRule(rule)
+				if err != nil {
+					logger.WithError(err).WithField("rule", i+1).Warn("Failed to evaluate product rule")
+
+					continue
+				}
+
+				sections = append(sections, texts...)
+			}
+		} else {
+			for i, event := range events {
+				if event.field != product.Name {
+					continue
+				}
+
+				end := len(flat.text)
+				if i+1 < len(events) {
+					end = events[i+1].start
+				}
+
+				sections = append(sections, flat.text[event.end:end])
+			}
+		}
+
+		pattern := product.Pattern
+		if pattern == "" {
+			pattern = defaultProductPattern
+		}
+
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			logger.WithError(err).Warn("Invalid product pattern")
+
+			continue
+		}
+
+		found := 0
+		for _, section := range sections {
+			for _, match := range re.FindAllStringSubmatch(cleanText(section), -1) {
+				price, err := parseProductPrice(re, match, locale)
+				if err != nil {
+					logger.WithFields(logrus.Fields{
+						"matched_row": match[0],
+						"error":       err,
+					}).Warn("Failed to parse product price")
+
+					continue
+				}
+
+				// The first row of a weight wins, later ones are usually repeated listings
+				key := product.Name + "|" + price.WeightGrams.String()
+				if seen[key] {
+					continue
+				}
+
+				seen[key] = true
+
+				price.Product = product.Name
+				prices = append(prices, *price)
+				found++
+			}
+		}
+
+		if found == 0 {
+			logger.Warn("Could not find any product price on the website")
+
+			continue
+		}
+
+		logger.WithField("found", found).Info()
+	}
+
+	return prices
+}
+
+// parseProductPrice reads the weight, jual and optional beli groups of a product row
+func parseProductPrice(re *regexp.Regexp, match []string, locale number.Locale) (*extractedProductPrice, error) {
+	weight, err := number.Parse(match[re.SubexpIndex("weight")], locale)
+	if err != nil {
+		return nil, fmt.Errorf("invalid weight: %w", err)
+	}
+
+	if !weight.IsPositive() {
+		return nil, fmt.Errorf("invalid weight: %s", weight)
+	}
+
+	jual, err := number.Parse(match[re.SubexpIndex("jual")], locale)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jual price: %w", err)
+	}
+
+	price := &extractedProductPrice{
+		WeightGrams: weight,
+		Jual:        jual,
+	}
+
+	if i := re.SubexpIndex("beli"); i >= 0 && match[i] != "" {
+		beli, err := number.Parse(match[i], locale)
+		if err != nil {
+			return nil, fmt.Errorf("invalid beli price: %w", err)
+		}
+
+		price.Beli = decimal.NewNullDecimal(beli)
+	}
+
+	return price, nil
+}
@@ -0,0 +1,493 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	cdptarget "github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/html/charset"
+)
+
+// Fetch modes
+const (
+	// FetchModeBrowser renders the page in a headless browser
+	FetchModeBrowser = "browser"
+	// FetchModeStatic downloads the page with a plain HTTP request
+	FetchModeStatic = "static"
+	// FetchModeAuto tries a static fetch first and falls back to the browser when it fails
+	FetchModeAuto = "auto"
+)
+
+const (
+	// defaultUserAgent is sent by static fetches, some sites reject the Go default one
+	defaultUserAgent = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36"
+
+	// maxPageSize limits how much of a page a static fetch reads
+	maxPageSize = 10 << 20
+)
+
+// crawlTarget holds everything needed to crawl one page
+type crawlTarget struct {
+	SetupId    string
+	Url        string
+	FetchMode  string
+	Extraction ExtractionSpec
+	Readiness  ReadinessSpec
+	Blocking   BlockingSpec
+	Rotation   RotationSpec
+	Steps      []BrowserStep
+	Session    SessionSpec
+	Emulation  EmulationSpec
+	Timeouts   TimeoutSpec
+}
+
+// crawlResult holds the prices extracted by a crawl
+type crawlResult struct {
+	Jual      extractedPrice
+	Beli      extractedPrice
+	Products  []extractedProductPrice
+	FetchMode string
+
+	// CapturedAt is when the page the prices came from was fetched, its snapshot is archived with the same time
+	CapturedAt time.Time
+}
+
+// crawlAttempt collects the pages fetched during one crawl attempt
+type crawlAttempt struct {
+	Number int
+	Pages  []*fetchedPage
+
+	// Identity is the proxy and user agent of the attempt, nil when the setup rotates nothing
+	Identity *crawlIdentity
+
+	// ErrorClass is empty when the attempt succeeded
+	ErrorClass ErrorClass
+
+	// Prices are the last prices extracted, nil when none were found
+	Prices *extractedPrices
+}
+
+// classify returns the class of the attempt's failure from the error and the last page fetched
+func (attempt *crawlAttempt) classify(err error) ErrorClass {
+	if len(attempt.Pages) == 0 {
+		return classifyError(err, 0, "")
+	}
+
+	page := attempt.Pages[len(attempt.Pages)-1]
+
+	return classifyError(err, page.StatusCode, page.Content)
+}
+
+// retryAfter returns the Retry-After hint of the last page fetched, zero when it sent none
+func (attempt *crawlAttempt) retryAfter() time.Duration {
+	if len(attempt.Pages) == 0 {
+		return 0
+	}
+
+	return attempt.Pages[len(attempt.Pages)-1].RetryAfter
+}
+
+// fetchedPage is a page downloaded or rendered during a crawl attempt
+type fetchedPage struct {
+	FetchMode  string
+	StatusCode int
+	Content    string
+	FetchedAt  time.Time
+	Err        error
+
+	// RetryAfter is how long the host asked to wait before trying again, zero when it did not say
+	RetryAfter time.Duration
+
+	// Proxy and UserAgent are empty when the defaults were used
+	Proxy     string
+	UserAgent string
+}
+
+// addPage records a new page fetched with the given mode
+func (attempt *crawlAttempt) addPage(fetchMode string) *fetchedPage {
+	page := &fetchedPage{
+		FetchMode: fetchMode,
+		FetchedAt: time.Now(),
+		Proxy:     attempt.Identity.proxyName(),
+		UserAgent: attempt.Identity.userAgent(),
+	}
+
+	attempt.Pages = append(attempt.Pages, page)
+
+	return page
+}
+
+// needsBrowser reports whether the target can only be crawled in a browser
+func (target *crawlTarget) needsBrowser() bool {
+	return len(target.Steps) > 0 || !target.Session.IsZero()
+}
+
+// ValidateFetchMode checks that the fetch mode is supported, an empty mode means browser
+func ValidateFetchMode(mode string) error {
+	switch mode {
+	case "", FetchModeBrowser, FetchModeStatic, FetchModeAuto:
+		return nil
+	default:
+		return fmt.Errorf("unsupported fetch mode: %q", mode)
+	}
+}
+
+// crawlStatic fetches the page with a plain HTTP request and extracts prices from the raw HTML
+func (service *Service) crawlStatic(ctx context.Context, target *crawlTarget, attempt *crawlAttempt, logger *logrus.Entry) (*crawlResult, error) {
+	logger = logger.WithField("used_fetch_mode", FetchModeStatic)
+
+	logger.WithFields(logrus.Fields{
+		"message": "Starting gold price crawling using static fetch",
+	}).Info()
+
+	startTime := time.Now()
+
+	err := service.waitPolitely(ctx, target.Url, attempt.Identity)
+	if err != nil {
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
+
+	fetched := attempt.addPage(FetchModeStatic)
+
+	navigationCtx, navigationCancel := withPhaseTimeout(ctx, PhaseNavigation, target.Timeouts.Navigation)
+	pageContent, statusCode, retryAfter, err := service.fetchStatic(navigationCtx, target.Url, attempt.Identity)
+	err = phaseTimeout(err, navigationCtx)
+	navigationCancel()
+
+	fetched.StatusCode = statusCode
+	fetched.Content = pageContent
+	fetched.RetryAfter = retryAfter
+	if err != nil {
+		err = fmt.Errorf("failed to fetch website statically: %w", err)
+		fetched.Err = err
+
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"fetch_duration_seconds": time.Since(startTime).Seconds(),
+		"content_length":         len(pageContent),
+	}).Info()
+
+	// No browser is attached, so js rules are skipped
+	page, err := newExtractionPage(pageContent, nil)
+	if err != nil {
+		fetched.Err = err
+
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
+
+	prices, err := service.extractGoldPrices(page, target.Extraction, logger)
+	attempt.Prices = prices
+	if err != nil {
+		fetched.Err = err
+
+		logger.WithError(err).Error()
+
+		logPageSample(logger, pageContent)
+
+		return nil, err
+	}
+
+	return &crawlResult{
+		Jual:       prices.Jual,
+		Beli:       prices.Beli,
+		Products:   service.extractProductPrices(page, target.Extraction, logger),
+		FetchMode:  FetchModeStatic,
+		CapturedAt: fetched.FetchedAt,
+	}, nil
+}
+
+// waitPolitely blocks until robots.txt and the host's rate limit let the page be fetched
+// robots.txt is fetched through the identity's proxy when one is given
+func (service *Service) waitPolitely(ctx context.Context, url string, identity *crawlIdentity) error {
+	if service.politeness == nil {
+		return nil
+	}
+
+	var client *http.Client
+	if identity != nil {
+		client = identity.client
+	}
+
+	err := service.politeness.Wait(ctx, url, client)
+	if err != nil {
+		return fmt.Errorf("fetch not allowed: %w", err)
+	}
+
+	return nil
+}
+
+// fetchStatic downloads the page and decodes it to UTF-8, returning it along with the HTTP status code and the Retry-After hint
+// The request goes through the identity's proxy with its user agent when one is given
+func (service *Service) fetchStatic(ctx context.Context, url string, identity *crawlIdentity) (string, int, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	userAgent := identity.userAgent()
+	if userAgent == "" {
+		userAgent = defaultUserAgent
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	client := service.httpClient
+	if identity != nil && identity.client != nil {
+		client = identity.client
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	defer resp.Body.Close()
+
+	retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+
+	reader, err := charset.NewReader(io.LimitReader(resp.Body, maxPageSize), resp.Header.Get("Content-Type"))
+	if err != nil {
+		return "", resp.StatusCode, retryAfter, fmt.Errorf("failed to decode response body: %w", err)
+	}
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return "", resp.StatusCode, retryAfter, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	// Error pages are returned too, they are worth archiving
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return string(body), resp.StatusCode, retryAfter, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return string(body), resp.StatusCode, retryAfter, nil
+}
+
+// crawlBrowser renders the page in a headless browser and extracts prices from the rendered DOM
+// This method handles JavaScript-rendered content properly
+func (service *Service) crawlBrowser(ctx context.Context, target *crawlTarget, attempt *crawlAttempt, logger *logrus.Entry) (*crawlResult, error) {
+	logger = logger.WithField("used_fetch_mode", FetchModeBrowser)
+
+	logger.WithFields(logrus.Fields{
+		"message": "Starting gold price crawling using headless browser",
+	}).Info()
+
+	if service.browsers == nil {
+		err := fmt.Errorf("browser crawls are not available: no browser pool")
+
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
+
+	// Only the page navigation is gated, the resources it loads are not
+	err := service.waitPolitely(ctx, target.Url, attempt.Identity)
+	if err != nil {
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
+
+	// Open a tab on one of the pooled browsers. Proxied tabs get their own browser context, and so do tabs with a
+	// session: the pooled browsers are shared by every setup, the default context would hand its cookies to them
+	var tabOptions []chromedp.ContextOption
+	if identity := attempt.Identity; identity != nil && identity.Proxy != nil {
+		// Chrome takes the proxy without credentials, they are given when the proxy asks
+		proxyServer := identity.Proxy.Scheme + "://" + identity.Proxy.Host
+
+		tabOptions = append(tabOptions, chromedp.WithNewBrowserContext(func(params *cdptarget.CreateBrowserContextParams) *cdptarget.CreateBrowserContextParams {
+			return params.WithProxyServer(proxyServer)
+		}))
+	} else if !target.Session.IsZero() {
+		tabOptions = append(tabOptions, chromedp.WithNewBrowserContext())
+	}
+
+	browserCtx, release, err := service.browsers.Tab(ctx, tabOptions...)
+	if err != nil {
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
+	defer release()
+
+	// The crawl on the tab runs until the attempt or the job runs out of time, the tab stays open until released
+	// so that failure artifacts are captured from it even after a timeout
+	crawlCtx := ctx
+	ctx, timeoutCancel := withPhaseDeadlineOf(browserCtx, crawlCtx)
+	defer timeoutCancel()
+
+	fetched := attempt.addPage(FetchModeBrowser)
+
+	var pageContent string
+
+	// Requests are tracked from the start so network idleness covers the whole page load
+	activity := watchNetwork(ctx)
+
+	// Unwanted resources and trackers are blocked before the first request
+	var proxy *url.URL
+	if attempt.Identity != nil {
+		proxy = attempt.Identity.Proxy
+	}
+
+	blocked, err := interceptRequests(ctx, target.Blocking, proxy, logger)
+	if err != nil {
+		fetched.Err = err
+
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
+
+	// The page must see the setup's device, languages and time zone from its first request
+	err = emulate(ctx, target.Emulation, attempt.Identity.userAgent(), target.Url)
+	if err != nil {
+		fetched.Err = err
+
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
+
+	// The stored cookies and localStorage must be in place before the first request
+	session := service.restoreSession(ctx, target, logger)
+
+	// Navigate to the gold price page, keeping its HTTP status
+	navigationCtx, navigationCancel := withPhaseTimeout(ctx, PhaseNavigation, target.Timeouts.Navigation)
+	response, err := chromedp.RunResponse(navigationCtx, chromedp.Navigate(target.Url))
+	if response != nil {
+		fetched.StatusCode = int(response.Status)
+		fetched.RetryAfter = parseRetryAfter(headerValue(response.Headers, "Retry-After"), time.Now())
+	}
+
+	if err == nil {
+		// Wait for the page to load
+		err = chromedp.Run(navigationCtx, chromedp.WaitVisible("body", chromedp.ByQuery))
+	}
+
+	err = phaseTimeout(err, navigationCtx, crawlCtx)
+	navigationCancel()
+
+	if err != nil {
+		err = fmt.Errorf("failed to scrape website with headless browser: %w", err)
+		fetched.Err = err
+
+		logger.WithError(err).Error()
+
+		service.captureFailureArtifacts(browserCtx, target, attempt, err, logger)
+
+		return nil, err
+	}
+
+	// Member prices are only shown once logged in
+	err = service.logIn(ctx, target, session, fetched, attempt.Identity, logger)
+	if err == nil {
+		// Run the setup's clicks, selections and scrolls that make the prices show up
+		err = service.runSteps(ctx, target.Steps, attempt.Identity, logger)
+	}
+
+	if err == nil {
+		// Wait for JavaScript to render the dynamic content, as told by the setup's readiness conditions
+		var readiness *readinessResult
+
+		readiness, err = waitReady(ctx, target.Readiness, activity)
+		if err == nil {
+			logReadiness(logger.WithField("blocked_requests", blocked.count.Load()), readiness)
+
+			service.saveSession(ctx, target, session, logger)
+		}
+	}
+
+	extractionCtx, extractionCancel := withPhaseTimeout(ctx, PhaseExtraction, target.Timeouts.Extraction)
+	defer extractionCancel()
+
+	if err == nil {
+		// Get the full page content for extraction
+		err = chromedp.Run(extractionCtx, chromedp.OuterHTML("html", &pageContent, chromedp.ByQuery))
+	}
+	fetched.Content = pageContent
+
+	err = phaseTimeout(err, extractionCtx, crawlCtx)
+	if err != nil {
+		err = fmt.Errorf("failed to scrape website with headless browser: %w", err)
+		fetched.Err = err
+
+		logger.WithError(err).Error()
+
+		service.captureFailureArtifacts(browserCtx, target, attempt, err, logger)
+
+		return nil, err
+	}
+
+	page, err := newExtractionPage(pageContent, browserEvaluator(extractionCtx))
+	if err != nil {
+		err = phaseTimeout(err, extractionCtx, crawlCtx)
+		fetched.Err = err
+
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
+
+	prices, err := service.extractGoldPrices(page, target.Extraction, logger)
+	attempt.Prices = prices
+	if err != nil {
+		err = phaseTimeout(err, extractionCtx, crawlCtx)
+		fetched.Err = err
+
+		logger.WithError(err).Error()
+
+		logPageSample(logger, pageContent)
+
+		service.captureFailureArtifacts(browserCtx, target, attempt, err, logger)
+
+		return nil, err
+	}
+
+	return &crawlResult{
+		Jual:       prices.Jual,
+		Beli:       prices.Beli,
+		Products:   service.extractProductPrices(page, target.Extraction, logger),
+		FetchMode:  FetchModeBrowser,
+		CapturedAt: fetched.FetchedAt,
+	}, nil
+}
+
+// logReadiness logs how long the page took to become ready and what it was still missing
+func logReadiness(logger *logrus.Entry, readiness *readinessResult) {
+	logger = logger.WithFields(logrus.Fields{
+		"readiness_wait_seconds": readiness.Wait.Seconds(),
+		"ready":                  readiness.Ready,
+	})
+
+	if !readiness.Ready {
+		logger.WithField("pending_conditions", readiness.Pending).Warn("Page not ready after max wait, extracting anyway")
+
+		return
+	}
+
+	logger.Info()
+}
+
+// logPageSample logs a sample of the page content for debugging
+func logPageSample(logger *logrus.Entry, pageContent string) {
+	contentSample := pageContent
+	if len(contentSample) > 1000 {
+		contentSample = contentSample[:1000] + "..."
+	}
+
+	logger.WithField("page_sample", contentSample).Debug("Page content sample")
+}
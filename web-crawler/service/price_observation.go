@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"web-crawler/store/sqlc"
+
+	"github.com/sirupsen/logrus"
+)
+
+type GetAllPriceObservationsParams struct {
+	Page int32
+	Size int32
+}
+
+type GetAllPriceObservationsResult struct {
+	PriceObservations []sqlc.IbdwhPriceObservation `json:"price_observations"`
+	Page              int32                        `json:"page"`
+	Size              int32                        `json:"size"`
+	Pages             int32                        `json:"pages"`
+	Total             int64                        `json:"total"`
+}
+
+func (service *Service) GetAllPriceObservations(ctx context.Context, params *GetAllPriceObservationsParams) (*GetAllPriceObservationsResult, error) {
+	const op = "[service] - Service.GetAllPriceObservations"
+
+	logger := service.logger.WithFields(logrus.Fields{
+		"[op]":   op,
+		"params": fmt.Sprintf("%+v", params),
+	})
+
+	logger.Info()
+
+	// Initialize result
+	result := &GetAllPriceObservationsResult{}
+
+	// Calculate limit and offset from page and size
+	limit := params.Size
+	offset := (params.Page - 1) * params.Size
+
+	observations, err := service.store.GetAllPriceObservations(ctx, sqlc.GetAllPriceObservationsParams{
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
+
+	// Get total count
+	total, err := service.store.GetTotalPriceObservations(ctx)
+	if err != nil {
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
+
+	// Calculate total pages
+	pages := (total + int64(params.Size) - 1) / int64(params.Size)
+
+	// Set result
+	result.PriceObservations = observations
+	result.Page = params.Page
+	result.Size = params.Size
+	result.Pages = int32(pages)
+	result.Total = total
+
+	return result, nil
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"web-crawler/store/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+type GetAllProductPricesParams struct {
+	Page int32
+	Size int32
+
+	// Optional filters, an empty product or a null weight matches everything
+	Product     string
+	WeightGrams decimal.NullDecimal
+}
+
+type GetAllProductPricesResult struct {
+	ProductPrices []sqlc.IbdwhProductPrice `json:"product_prices"`
+	Page          int32                    `json:"page"`
+	Size          int32                    `json:"size"`
+	Pages         int32                    `json:"pages"`
+	Total         int64                    `json:"total"`
+}
+
+func (service *Service) GetAllProductPrices(ctx context.Context, params *GetAllProductPricesParams) (*GetAllProductPricesResult, error) {
+	const op = "[service] - Service.GetAllProductPrices"
+
+	logger := service.logger.WithFields(logrus.Fields{
+		"[op]":   op,
+		"params": fmt.Sprintf("%+v", params),
+	})
+
+	logger.Info()
+
+	// Initialize result
+	result := &GetAllProductPricesResult{}
+
+	// Calculate limit and offset from page and size
+	limit := params.Size
+	offset := (params.Page - 1) * params.Size
+
+	product := pgtype.Text{
+		String: params.Product,
+		Valid:  params.Product != "",
+	}
+
+	productPrices, err := service.store.GetAllProductPrices(ctx, sqlc.GetAllProductPricesParams{
+		Product:     product,
+		WeightGrams: params.WeightGrams,
+		Limit:       limit,
+		Offset:      offset,
+	})
+	if err != nil {
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
+
+	// Get total count
+	total, err := service.store.GetTotalProductPrices(ctx, sqlc.GetTotalProductPricesParams{
+		Product:     product,
+		WeightGrams: params.WeightGrams,
+	})
+	if err != nil {
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
+
+	// Calculate total pages
+	pages := (total + int64(params.Size) - 1) / int64(params.Size)
+
+	// Set result
+	result.ProductPrices = productPrices
+	result.Page = params.Page
+	result.Size = params.Size
+	result.Pages = int32(pages)
+	result.Total = total
+
+	return result, nil
+}
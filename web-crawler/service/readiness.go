@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	// defaultReadinessMaxWait bounds the wait for a rendered page when the spec leaves it unset
+	defaultReadinessMaxWait = 10 * time.Second
+
+	// defaultNetworkIdle is how long the network must be quiet when no condition is set
+	defaultNetworkIdle = 500 * time.Millisecond
+
+	// readinessPollInterval is how often the readiness conditions are evaluated
+	readinessPollInterval = 100 * time.Millisecond
+)
+
+// ReadinessSpec declares when a page rendered in the browser is ready for extraction.
+//
+// Every condition that is set must hold at the same time: Selector is visible,
+// Text matches the visible page text, no request has been in flight for
+// NetworkIdle and the Js expression is truthy. A spec without conditions waits
+// for the network to be idle for 500ms. Extraction starts anyway once MaxWait
+// has passed, so a slow page fails on its missing prices instead of here.
+type ReadinessSpec struct {
+	Selector    string
+	Text        string
+	NetworkIdle time.Duration
+	Js          string
+	MaxWait     time.Duration
+}
+
+// IsZero reports whether no readiness condition is configured
+func (spec ReadinessSpec) IsZero() bool {
+	return spec.Selector == "" && spec.Text == "" && spec.NetworkIdle == 0 && spec.Js == ""
+}
+
+// Validate checks that the readiness conditions can be evaluated
+func (spec ReadinessSpec) Validate() error {
+	if spec.Selector != "" {
+		if _, err := cascadia.Compile(spec.Selector); err != nil {
+			return fmt.Errorf("invalid selector: %w", err)
+		}
+	}
+
+	if spec.Text != "" {
+		if _, err := regexp.Compile(spec.Text); err != nil {
+			return fmt.Errorf("invalid text pattern: %w", err)
+		}
+	}
+
+	if spec.NetworkIdle < 0 {
+		return fmt.Errorf("network idle must not be negative")
+	}
+
+	if spec.MaxWait < 0 {
+		return fmt.Errorf("max wait must not be negative")
+	}
+
+	return nil
+}
+
+// networkActivity tracks the requests of a tab to tell when its network is idle
+type networkActivity struct {
+	mutex        sync.Mutex
+	inflight     map[network.RequestID]bool
+	lastActivity time.Time
+}
+
+// watchNetwork starts tracking the requests of the tab, it must be called before navigating
+func watchNetwork(ctx context.Context) *networkActivity {
+	activity := &networkActivity{
+		inflight:     map[network.RequestID]bool{},
+		lastActivity: time.Now(),
+	}
+
+	chromedp.ListenTarget(ctx, func(ev any) {
+		activity.mutex.Lock()
+		defer activity.mutex.Unlock()
+
+		switch ev := ev.(type) {
+		case *network.EventRequestWillBeSent:
+			activity.inflight[ev.RequestID] = true
+		case *network.EventLoadingFinished:
+			delete(activity.inflight, ev.RequestID)
+		case *network.EventLoadingFailed:
+			delete(activity.inflight, ev.RequestID)
+		default:
+			return
+		}
+
+		activity.lastActivity = time.Now()
+	})
+
+	return activity
+}
+
+// idleFor returns how long no request has been in flight, 0 while one is
+func (activity *networkActivity) idleFor() time.Duration {
+	activity.mutex.Lock()
+	defer activity.mutex.Unlock()
+
+	if len(activity.inflight) > 0 {
+		return 0
+	}
+
+	return time.Since(activity.lastActivity)
+}
+
+// readinessResult tells how long a page took to become ready
+type readinessResult struct {
+	Ready bool
+	Wait  time.Duration
+
+	// Pending lists the conditions still unmet when the max wait ran out
+	Pending []string
+}
+
+// waitReady polls the readiness conditions of the spec until they all hold or the max wait has passed
+// An error is only returned when the tab itself fails
+func waitReady(ctx context.Context, spec ReadinessSpec, activity *networkActivity) (*readinessResult, error) {
+	if spec.IsZero() {
+		spec.NetworkIdle = defaultNetworkIdle
+	}
+
+	maxWait := spec.MaxWait
+	if maxWait <= 0 {
+		maxWait = defaultReadinessMaxWait
+	}
+
+	var text *regexp.Regexp
+	if spec.Text != "" {
+		var err error
+
+		text, err = regexp.Compile(spec.Text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid readiness text pattern: %w", err)
+		}
+	}
+
+	startTime := time.Now()
+	deadline := startTime.Add(maxWait)
+
+	for {
+		var pending []string
+
+		if spec.Selector != "" {
+			visible, err := evaluateBool(ctx, selectorVisibleExpression(spec.Selector))
+			if err != nil {
+				return nil, err
+			}
+
+			if !visible {
+				pending = append(pending, fmt.Sprintf("selector %q visible", spec.Selector))
+			}
+		}
+
+		if text != nil {
+			var pageText string
+
+			err := chromedp.Run(ctx, chromedp.Evaluate(`document.body ? document.body.innerText : ""`, &pageText))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read page text: %w", err)
+			}
+
+			if !text.MatchString(pageText) {
+				pending = append(pending, fmt.Sprintf("text %q present", spec.Text))
+			}
+		}
+
+		if spec.NetworkIdle > 0 && activity.idleFor() < spec.NetworkIdle {
+			pending = append(pending, fmt.Sprintf("network idle for %s", spec.NetworkIdle))
+		}
+
+		if spec.Js != "" {
+			// A predicate throwing while the page is loading is treated as not ready yet
+			ready, err := evaluateBool(ctx, "(() => { try { return !!("+spec.Js+"); } catch (e) { return false; } })()")
+			if err != nil {
+				return nil, err
+			}
+
+			if !ready {
+				pending = append(pending, fmt.Sprintf("js %q true", spec.Js))
+			}
+		}
+
+		if len(pending) == 0 || !time.Now().Before(deadline) {
+			return &readinessResult{
+				Ready:   len(pending) == 0,
+				Wait:    time.Since(startTime),
+				Pending: pending,
+			}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to wait for page readiness: %w", ctx.Err())
+		case <-time.After(readinessPollInterval):
+		}
+	}
+}
+
+// evaluateBool runs a JS expression in the tab and reports whether it returned true
+func evaluateBool(ctx context.Context, expression string) (bool, error) {
+	var value bool
+
+	err := chromedp.Run(ctx, chromedp.Evaluate(expression, &value))
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate readiness condition: %w", err)
+	}
+
+	return value, nil
+}
+
+// selectorVisibleExpression returns a JS expression telling whether an element matching the selector is displayed
+func selectorVisibleExpression(selector string) string {
+	quoted, _ := json.Marshal(selector)
+
+	return `(() => {
+		const element = document.querySelector(` + string(quoted) + `);
+		if (!element) return false;
+		const style = window.getComputedStyle(element);
+		const rect = element.getBoundingClientRect();
+		return style.visibility !== "hidden" && style.display !== "none" && rect.width > 0 && rect.height > 0;
+	})()`
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"web-crawler/store/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+type ReplayEmasParams struct {
+	Content    string
+	Extraction ExtractionSpec
+
+	// SetupId and Url are the setup and page the snapshot was captured for, the setup is required to write
+	SetupId string
+	Url     string
+
+	// CreatedAt is when the page was captured, it becomes the observation time when no crawled observation is replaced
+	CreatedAt time.Time
+
+	// CapturedAt is the capture time recorded with the snapshot, zero for pages saved by hand.
+	// It is kept as archived, observations crawled from the same page are matched by it
+	CapturedAt time.Time
+
+	// Write replaces the observation crawled from the page with the extracted prices and rolls up the day again
+	Write bool
+}
+
+type ReplayEmasResult struct {
+	Jual      decimal.Decimal
+	JualLabel string
+	Beli      decimal.Decimal
+	BeliLabel string
+
+	// ID is the emas row rolled up, empty when nothing was written or the setup does not feed ibdwh.emas
+	ID string
+
+	// ObservationID is the observation written, zero when nothing was written
+	ObservationID int64
+
+	// ReplacedObservationIDs are the observations crawled from the page that were deleted for the written one
+	ReplacedObservationIDs []int64
+}
+
+// ReplayEmas runs the extraction spec against a saved page, without any browser or network access
+func (service *Service) ReplayEmas(ctx context.Context, params *ReplayEmasParams) (*ReplayEmasResult, error) {
+	const op = "[service] - Service.ReplayEmas"
+
+	logger := service.logger.WithFields(logrus.Fields{
+		"[op]":           op,
+		"setup_id":       params.SetupId,
+		"created_at":     params.CreatedAt,
+		"captured_at":    params.CapturedAt,
+		"content_length": len(params.Content),
+		"write":          params.Write,
+	})
+
+	logger.Info()
+
+	// Fall back to the Pegadaian extraction spec when none is configured
+	spec := params.Extraction
+	if spec.IsZero() {
+		spec = DefaultExtractionSpec()
+	}
+
+	err := spec.Validate()
+	if err != nil {
+		err = fmt.Errorf("invalid extraction spec: %w", err)
+
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
+
+	// No browser is attached, so js rules are skipped
+	page, err := newExtractionPage(params.Content, nil)
+	if err != nil {
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
+
+	prices, err := service.extractGoldPrices(page, spec, logger)
+	if err != nil {
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
+
+	// Set result
+	result := &ReplayEmasResult{
+		Jual:      prices.Jual.Value,
+		JualLabel: prices.Jual.Label,
+		Beli:      prices.Beli.Value,
+		BeliLabel: prices.Beli.Label,
+	}
+
+	if !params.Write {
+		return result, nil
+	}
+
+	if params.CreatedAt.IsZero() || params.SetupId == "" {
+		err = fmt.Errorf("capture time and setup are required to write an observation")
+
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
+
+	// The corrected prices replace the ones crawled from the same page and go through price_observations like a crawl,
+	// so both rollups are computed again without the bad observation
+	err = service.store.WithTx(ctx, func(q *sqlc.Queries) error {
+		observedAt := params.CreatedAt
+
+		if !params.CapturedAt.IsZero() {
+			replaced, err := q.DeletePriceObservationsByCapture(ctx, sqlc.DeletePriceObservationsByCaptureParams{
+				SetupID: params.SetupId,
+				CapturedAt: pgtype.Timestamp{
+					Time:  params.CapturedAt,
+					Valid: true,
+				},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to delete the observation crawled from the page: %w", err)
+			}
+
+			for _, observation := range replaced {
+				result.ReplacedObservationIDs = append(result.ReplacedObservationIDs, observation.ObservationID)
+			}
+
+			// The replacement keeps the time of the crawled observation, so it lands on the same day and in the same order
+			if len(replaced) > 0 {
+				observedAt = replaced[0].ObservedAt.Time
+			}
+		}
+
+		stored, err := storeObservation(ctx, q, &observation{
+			SetupId:    params.SetupId,
+			Url:        params.Url,
+			ObservedAt: observedAt,
+			Jual:       prices.Jual,
+			Beli:       prices.Beli,
+			CapturedAt: params.CapturedAt,
+			Product:    spec.product(),
+			Products:   service.extractProductPrices(page, spec, logger),
+		})
+		if err != nil {
+			return err
+		}
+
+		result.ID = stored.ID
+		result.ObservationID = stored.ObservationID
+
+		return nil
+	})
+	if err != nil {
+		err = fmt.Errorf("failed to write observation: %w", err)
+
+		logger.WithError(err).Error()
+
+		return nil, err
+	}
+
+	logger.WithFields(logrus.Fields{
+		"emas_id":                  result.ID,
+		"observation_id":           result.ObservationID,
+		"replaced_observation_ids": result.ReplacedObservationIDs,
+	}).Info("Replayed prices written")
+
+	return result, nil
+}
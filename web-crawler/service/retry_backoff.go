@@ -1,40 +1,274 @@
 package service
 
 import (
+	"fmt"
 	"math"
 	"math/rand"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+// Backoff strategies
+const (
+	// BackoffConstant waits the initial delay before every retry
+	BackoffConstant = "constant"
+	// BackoffLinear waits the initial delay times the attempt number
+	BackoffLinear = "linear"
+	// BackoffExponential multiplies the initial delay by the backoff factor after every attempt
+	BackoffExponential = "exponential"
+	// BackoffFullJitter waits a random delay between zero and the exponential delay
+	BackoffFullJitter = "full_jitter"
+	// BackoffEqualJitter waits half the exponential delay plus a random delay up to the other half
+	BackoffEqualJitter = "equal_jitter"
+	// BackoffDecorrelatedJitter waits a random delay between the initial delay and three times the previous delay
+	BackoffDecorrelatedJitter = "decorrelated_jitter"
 )
 
+// DefaultMaxRetryAfter is the longest Retry-After hint waited for when the retry config leaves it unset
+const DefaultMaxRetryAfter = 5 * time.Minute
+
 type RetryConfig struct {
 	MaxAttempts   int
 	InitialDelay  time.Duration
 	MaxDelay      time.Duration
 	BackoffFactor float64
 	EnableJitter  bool
+
+	// Strategy is how delays grow between retries, empty means exponential.
+	// EnableJitter only applies to the constant, linear and exponential strategies
+	Strategy string
+
+	// IgnoreRetryAfter keeps the strategy's delay when the failed page sent a Retry-After header
+	IgnoreRetryAfter bool
+
+	// MaxRetryAfter is the longest Retry-After hint waited for, the crawl stops on longer ones
+	MaxRetryAfter time.Duration
+
+	// Policies override the retries of an error class, classes left out use defaultRetryPolicies
+	Policies map[ErrorClass]RetryPolicy
+}
+
+// RetryPolicy is how an error class is retried, zero values fall back to the retry config
+type RetryPolicy struct {
+	// MaxAttempts stops the crawl once this many attempts were made and the last one failed with the class
+	MaxAttempts int
+
+	// InitialDelay replaces the retry config's initial delay before retrying the class
+	InitialDelay time.Duration
 }
 
-// calculateBackoffDelay calculates the delay for exponential backoff with optional jitter
-func (service *Service) calculateBackoffDelay(attempt int, retryConfig RetryConfig) time.Duration {
-	// Calculate exponential backoff delay
-	exponentialDelay := time.Duration(float64(retryConfig.InitialDelay) * math.Pow(retryConfig.BackoffFactor, float64(attempt-1)))
+// defaultRetryPolicies keeps failures that are unlikely to go away from using up every attempt
+var defaultRetryPolicies = map[ErrorClass]RetryPolicy{
+	ErrorClassHttp:   {MaxAttempts: 2},
+	ErrorClassParse:  {MaxAttempts: 2},
+	ErrorClassSanity: {MaxAttempts: 2},
+}
+
+// Validate checks the backoff strategy and that policies name known classes, politeness violations are never retried and cannot be overridden
+func (retryConfig RetryConfig) Validate() error {
+	switch retryConfig.Strategy {
+	case "", BackoffConstant, BackoffLinear, BackoffExponential, BackoffFullJitter, BackoffEqualJitter, BackoffDecorrelatedJitter:
+	default:
+		return fmt.Errorf("unsupported backoff strategy: %q", retryConfig.Strategy)
+	}
 
-	// Cap the delay at MaxDelay
-	if exponentialDelay > retryConfig.MaxDelay {
-		exponentialDelay = retryConfig.MaxDelay
+	if retryConfig.InitialDelay < 0 || retryConfig.MaxDelay < 0 || retryConfig.BackoffFactor < 0 || retryConfig.MaxRetryAfter < 0 {
+		return fmt.Errorf("delays and backoff factor must not be negative")
 	}
 
-	// Add jitter if enabled (±25% randomization)
-	if retryConfig.EnableJitter {
-		jitterRange := float64(exponentialDelay) * 0.25
-		jitter := time.Duration(rand.Float64()*jitterRange*2 - jitterRange)
-		exponentialDelay += jitter
+	for class, policy := range retryConfig.Policies {
+		if !slices.Contains(errorClasses, class) {
+			return fmt.Errorf("unsupported error class: %q", class)
+		}
+
+		if class == ErrorClassPoliteness {
+			return fmt.Errorf("politeness violations are never retried, their policy cannot be changed")
+		}
+
+		if policy.MaxAttempts < 0 || policy.InitialDelay < 0 {
+			return fmt.Errorf("%s policy must not be negative", class)
+		}
+	}
+
+	return nil
+}
+
+// policy returns the retry config applying after a failure of the given class
+func (retryConfig RetryConfig) policy(class ErrorClass) (maxAttempts int, config RetryConfig) {
+	if class == ErrorClassPoliteness {
+		return 1, retryConfig
+	}
+
+	policy, found := retryConfig.Policies[class]
+	if !found {
+		policy = defaultRetryPolicies[class]
+	}
+
+	maxAttempts = retryConfig.MaxAttempts
+	if policy.MaxAttempts > 0 {
+		maxAttempts = min(policy.MaxAttempts, retryConfig.MaxAttempts)
+	}
+
+	if policy.InitialDelay > 0 {
+		retryConfig.InitialDelay = policy.InitialDelay
+		retryConfig.MaxDelay = max(retryConfig.MaxDelay, policy.InitialDelay)
+	}
+
+	return maxAttempts, retryConfig
+}
+
+// maxRetryAfter returns the longest Retry-After hint waited for
+func (retryConfig RetryConfig) maxRetryAfter() time.Duration {
+	if retryConfig.MaxRetryAfter > 0 {
+		return retryConfig.MaxRetryAfter
+	}
+
+	return DefaultMaxRetryAfter
+}
+
+// retryDelay returns the wait before the next attempt, a Retry-After hint longer than the backoff delay wins.
+// It is not ok when the hint is longer than MaxRetryAfter, the crawl then stops
+func (retryConfig RetryConfig) retryDelay(delay time.Duration, retryAfter time.Duration) (time.Duration, bool) {
+	if retryAfter <= 0 || retryConfig.IgnoreRetryAfter {
+		return delay, true
+	}
+
+	if retryAfter > retryConfig.maxRetryAfter() {
+		return delay, false
+	}
+
+	return max(delay, retryAfter), true
+}
+
+// backoff computes the delays between the retries of one crawl.
+// Its random source is given so that the jittered delays can be reproduced
+type backoff struct {
+	random *rand.Rand
+
+	// previous is the last delay, decorrelated jitter grows from it
+	previous time.Duration
+}
+
+func newBackoff(source rand.Source) *backoff {
+	return &backoff{
+		random: rand.New(source),
+	}
+}
+
+// delay returns how long to wait after the given failed attempt, according to the config's strategy and capped at its max delay
+func (backoff *backoff) delay(attempt int, retryConfig RetryConfig) time.Duration {
+	var delay time.Duration
+
+	switch retryConfig.Strategy {
+	case BackoffConstant:
+		delay = capDelay(retryConfig.InitialDelay, retryConfig)
+	case BackoffLinear:
+		delay = capDelay(time.Duration(float64(retryConfig.InitialDelay)*float64(attempt)), retryConfig)
+	case BackoffFullJitter:
+		delay = backoff.between(0, exponentialDelay(attempt, retryConfig))
+	case BackoffEqualJitter:
+		half := exponentialDelay(attempt, retryConfig) / 2
+		delay = half + backoff.between(0, half)
+	case BackoffDecorrelatedJitter:
+		previous := max(backoff.previous, retryConfig.InitialDelay)
+		delay = capDelay(backoff.between(retryConfig.InitialDelay, previous*3), retryConfig)
+	default:
+		delay = exponentialDelay(attempt, retryConfig)
+	}
+
+	// The jitter strategies are random already
+	switch retryConfig.Strategy {
+	case "", BackoffConstant, BackoffLinear, BackoffExponential:
+		if retryConfig.EnableJitter {
+			delay = capDelay(backoff.jitter(delay, retryConfig), retryConfig)
+		}
+	}
+
+	backoff.previous = delay
+
+	return delay
+}
+
+// jitter randomizes the delay by ±25%
+func (backoff *backoff) jitter(delay time.Duration, retryConfig RetryConfig) time.Duration {
+	jitterRange := float64(delay) * 0.25
+	jitter := time.Duration(backoff.random.Float64()*jitterRange*2 - jitterRange)
+	delay += jitter
+
+	// Ensure delay is not negative
+	if delay < 0 {
+		delay = retryConfig.InitialDelay
+	}
+
+	return delay
+}
+
+// between returns a random delay in [low, high]
+func (backoff *backoff) between(low time.Duration, high time.Duration) time.Duration {
+	if high <= low {
+		return low
+	}
+
+	return low + time.Duration(backoff.random.Int63n(int64(high-low)+1))
+}
+
+// exponentialDelay multiplies the initial delay by the backoff factor once per previous attempt, capped at the max delay
+func exponentialDelay(attempt int, retryConfig RetryConfig) time.Duration {
+	delay := float64(retryConfig.InitialDelay) * math.Pow(retryConfig.BackoffFactor, float64(attempt-1))
+
+	// Large factors overflow a duration
+	if delay > float64(math.MaxInt64) {
+		return capDelay(time.Duration(math.MaxInt64), retryConfig)
+	}
+
+	return capDelay(time.Duration(delay), retryConfig)
+}
+
+// capDelay caps the delay at the config's max delay
+func capDelay(delay time.Duration, retryConfig RetryConfig) time.Duration {
+	if delay > retryConfig.MaxDelay {
+		return retryConfig.MaxDelay
+	}
+
+	return delay
+}
+
+// parseRetryAfter reads a Retry-After header, given either in seconds or as an HTTP date, zero when missing or invalid
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+
+		return time.Duration(seconds) * time.Second
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil || !date.After(now) {
+		return 0
+	}
+
+	return date.Sub(now)
+}
 
-		// Ensure delay is not negative
-		if exponentialDelay < 0 {
-			exponentialDelay = retryConfig.InitialDelay
+// headerValue returns a header of a browser response, whose names are not canonicalized
+func headerValue(headers network.Headers, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return fmt.Sprint(value)
 		}
 	}
 
-	return exponentialDelay
+	return ""
 }
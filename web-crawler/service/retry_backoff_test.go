@@ -0,0 +1,322 @@
+package service
+
+import (
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// seed makes the jittered delays reproducible
+const seed = 42
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		want     []time.Duration
+	}{
+		{
+			name:     "constant",
+			strategy: BackoffConstant,
+			want:     []time.Duration{time.Second, time.Second, time.Second, time.Second},
+		},
+		{
+			name:     "linear",
+			strategy: BackoffLinear,
+			want:     []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second},
+		},
+		{
+			name:     "exponential",
+			strategy: BackoffExponential,
+			want:     []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second},
+		},
+		{
+			name:     "empty strategy is exponential",
+			strategy: "",
+			want:     []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			retryConfig := RetryConfig{
+				Strategy:      test.strategy,
+				InitialDelay:  time.Second,
+				MaxDelay:      time.Minute,
+				BackoffFactor: 2,
+			}
+
+			backoff := newBackoff(rand.NewSource(seed))
+
+			for i, want := range test.want {
+				got := backoff.delay(i+1, retryConfig)
+				if got != want {
+					t.Errorf("attempt %d: got %s, want %s", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBackoffDelayJitter(t *testing.T) {
+	retryConfig := RetryConfig{
+		InitialDelay:  time.Second,
+		MaxDelay:      time.Minute,
+		BackoffFactor: 2,
+	}
+
+	tests := []struct {
+		name     string
+		strategy string
+		jitter   bool
+		low      func(exponential time.Duration) time.Duration
+		high     func(exponential time.Duration) time.Duration
+	}{
+		{
+			name:     "full jitter",
+			strategy: BackoffFullJitter,
+			low:      func(time.Duration) time.Duration { return 0 },
+			high:     func(exponential time.Duration) time.Duration { return exponential },
+		},
+		{
+			name:     "equal jitter",
+			strategy: BackoffEqualJitter,
+			low:      func(exponential time.Duration) time.Duration { return exponential / 2 },
+			high:     func(exponential time.Duration) time.Duration { return exponential },
+		},
+		{
+			name:     "exponential with jitter",
+			strategy: BackoffExponential,
+			jitter:   true,
+			low:      func(exponential time.Duration) time.Duration { return exponential * 3 / 4 },
+			high:     func(exponential time.Duration) time.Duration { return exponential * 5 / 4 },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			retryConfig := retryConfig
+			retryConfig.Strategy = test.strategy
+			retryConfig.EnableJitter = test.jitter
+
+			backoff := newBackoff(rand.NewSource(seed))
+
+			for attempt := 1; attempt <= 5; attempt++ {
+				exponential := exponentialDelay(attempt, retryConfig)
+
+				got := backoff.delay(attempt, retryConfig)
+				if got < test.low(exponential) || got > test.high(exponential) {
+					t.Errorf("attempt %d: got %s, want between %s and %s", attempt, got, test.low(exponential), test.high(exponential))
+				}
+			}
+		})
+	}
+}
+
+func TestBackoffDelaySameSeed(t *testing.T) {
+	retryConfig := RetryConfig{
+		Strategy:      BackoffDecorrelatedJitter,
+		InitialDelay:  time.Second,
+		MaxDelay:      time.Minute,
+		BackoffFactor: 2,
+	}
+
+	first := newBackoff(rand.NewSource(seed))
+	second := newBackoff(rand.NewSource(seed))
+
+	for attempt := 1; attempt <= 5; attempt++ {
+		if got, want := second.delay(attempt, retryConfig), first.delay(attempt, retryConfig); got != want {
+			t.Errorf("attempt %d: got %s, want %s from the same seed", attempt, got, want)
+		}
+	}
+}
+
+func TestBackoffDelayDecorrelatedJitter(t *testing.T) {
+	retryConfig := RetryConfig{
+		Strategy:     BackoffDecorrelatedJitter,
+		InitialDelay: time.Second,
+		MaxDelay:     time.Hour,
+	}
+
+	backoff := newBackoff(rand.NewSource(seed))
+
+	previous := retryConfig.InitialDelay
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		got := backoff.delay(attempt, retryConfig)
+
+		// Every delay is drawn between the initial delay and three times the previous one
+		if got < retryConfig.InitialDelay || got > previous*3 {
+			t.Errorf("attempt %d: got %s, want between %s and %s", attempt, got, retryConfig.InitialDelay, previous*3)
+		}
+
+		if backoff.previous != got {
+			t.Errorf("attempt %d: previous delay is %s, want %s", attempt, backoff.previous, got)
+		}
+
+		previous = max(got, retryConfig.InitialDelay)
+	}
+}
+
+func TestBackoffDelayDecorrelatedJitterGrows(t *testing.T) {
+	retryConfig := RetryConfig{
+		Strategy:     BackoffDecorrelatedJitter,
+		InitialDelay: time.Second,
+		MaxDelay:     time.Hour,
+	}
+
+	backoff := newBackoff(rand.NewSource(seed))
+
+	// A long previous delay lets the next one go well past what the initial delay alone allows
+	backoff.previous = 10 * time.Minute
+
+	got := backoff.delay(1, retryConfig)
+	if got < retryConfig.InitialDelay || got > 30*time.Minute {
+		t.Errorf("got %s, want between %s and %s", got, retryConfig.InitialDelay, 30*time.Minute)
+	}
+
+	// Without a previous delay it is bounded by three times the initial delay
+	fresh := newBackoff(rand.NewSource(seed))
+
+	got = fresh.delay(1, retryConfig)
+	if got < retryConfig.InitialDelay || got > 3*retryConfig.InitialDelay {
+		t.Errorf("got %s, want between %s and %s", got, retryConfig.InitialDelay, 3*retryConfig.InitialDelay)
+	}
+}
+
+func TestBackoffDelayMaxDelay(t *testing.T) {
+	strategies := []string{
+		BackoffConstant,
+		BackoffLinear,
+		BackoffExponential,
+		BackoffFullJitter,
+		BackoffEqualJitter,
+		BackoffDecorrelatedJitter,
+	}
+
+	for _, strategy := range strategies {
+		for _, jitter := range []bool{false, true} {
+			retryConfig := RetryConfig{
+				Strategy:      strategy,
+				InitialDelay:  4 * time.Second,
+				MaxDelay:      5 * time.Second,
+				BackoffFactor: 3,
+				EnableJitter:  jitter,
+			}
+
+			backoff := newBackoff(rand.NewSource(seed))
+
+			for attempt := 1; attempt <= 20; attempt++ {
+				got := backoff.delay(attempt, retryConfig)
+				if got > retryConfig.MaxDelay {
+					t.Errorf("%s (jitter %t) attempt %d: got %s, want at most %s", strategy, jitter, attempt, got, retryConfig.MaxDelay)
+				}
+			}
+		}
+	}
+
+	// Large factors would overflow a duration
+	retryConfig := RetryConfig{
+		Strategy:      BackoffExponential,
+		InitialDelay:  time.Second,
+		MaxDelay:      time.Minute,
+		BackoffFactor: 1000,
+	}
+
+	if got := newBackoff(rand.NewSource(seed)).delay(50, retryConfig); got != time.Minute {
+		t.Errorf("got %s, want %s", got, time.Minute)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "delta seconds", value: "120", want: 2 * time.Minute},
+		{name: "delta seconds with spaces", value: " 30 ", want: 30 * time.Second},
+		{name: "zero seconds", value: "0", want: 0},
+		{name: "negative seconds", value: "-5", want: 0},
+		{name: "http date", value: now.Add(90 * time.Second).Format(http.TimeFormat), want: 90 * time.Second},
+		{name: "past http date", value: now.Add(-time.Hour).Format(http.TimeFormat), want: 0},
+		{name: "now", value: now.Format(http.TimeFormat), want: 0},
+		{name: "garbage", value: "soon", want: 0},
+		{name: "empty", value: "", want: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := parseRetryAfter(test.value, now); got != test.want {
+				t.Errorf("parseRetryAfter(%q) = %s, want %s", test.value, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name        string
+		retryConfig RetryConfig
+		delay       time.Duration
+		retryAfter  time.Duration
+		want        time.Duration
+		wantOk      bool
+	}{
+		{
+			name:       "retry after wins over a shorter backoff",
+			delay:      2 * time.Second,
+			retryAfter: 30 * time.Second,
+			want:       30 * time.Second,
+			wantOk:     true,
+		},
+		{
+			name:       "longer backoff wins over retry after",
+			delay:      time.Minute,
+			retryAfter: 30 * time.Second,
+			want:       time.Minute,
+			wantOk:     true,
+		},
+		{
+			name:   "no retry after",
+			delay:  2 * time.Second,
+			want:   2 * time.Second,
+			wantOk: true,
+		},
+		{
+			name:        "ignored retry after",
+			retryConfig: RetryConfig{IgnoreRetryAfter: true},
+			delay:       2 * time.Second,
+			retryAfter:  time.Hour,
+			want:        2 * time.Second,
+			wantOk:      true,
+		},
+		{
+			name:       "retry after past the default max",
+			delay:      2 * time.Second,
+			retryAfter: DefaultMaxRetryAfter + time.Second,
+			want:       2 * time.Second,
+			wantOk:     false,
+		},
+		{
+			name:        "retry after within a raised max",
+			retryConfig: RetryConfig{MaxRetryAfter: time.Hour},
+			delay:       2 * time.Second,
+			retryAfter:  30 * time.Minute,
+			want:        30 * time.Minute,
+			wantOk:      true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := test.retryConfig.retryDelay(test.delay, test.retryAfter)
+			if got != test.want || ok != test.wantOk {
+				t.Errorf("got %s, %t, want %s, %t", got, ok, test.want, test.wantOk)
+			}
+		})
+	}
+}
@@ -1,6 +1,13 @@
 package service
 
 import (
+	"net/http"
+	"sync"
+
+	"web-crawler/archive"
+	"web-crawler/breaker"
+	"web-crawler/browser"
+	"web-crawler/politeness"
 	"web-crawler/store"
 
 	"github.com/sirupsen/logrus"
@@ -10,15 +17,50 @@ type Service struct {
 	logger *logrus.Logger
 
 	store store.IStore
+
+	// archive keeps the raw page of every crawl attempt, nil when disabled
+	archive archive.Archive
+
+	// browsers hands out the tabs of browser crawls, nil when no page is rendered
+	browsers *browser.Pool
+
+	// politeness gates every page fetch on robots.txt and the per host rate limit, nil when fetches are not gated
+	politeness *politeness.Politeness
+
+	// breakers skip the crawls of hosts that keep failing, nil when every crawl goes out
+	breakers *breaker.Breakers
+
+	httpClient *http.Client
+
+	// rotations keeps the proxy and user agent rotation of every setup across crawls
+	rotationsMutex sync.Mutex
+	rotations      map[string]*rotation
 }
 
 func NewService(
 	logger *logrus.Logger,
 	store store.IStore,
+	archive archive.Archive,
+	browsers *browser.Pool,
+	politeness *politeness.Politeness,
+	breakers *breaker.Breakers,
 ) *Service {
 	return &Service{
 		logger: logger,
 
 		store: store,
+
+		archive: archive,
+
+		browsers: browsers,
+
+		politeness: politeness,
+
+		breakers: breakers,
+
+		// Static fetches are bounded by the navigation and attempt timeouts of their setup
+		httpClient: &http.Client{},
+
+		rotations: map[string]*rotation{},
 	}
 }
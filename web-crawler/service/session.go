@@ -0,0 +1,430 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"web-crawler/store/sqlc"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultLoginTimeout bounds a login when the spec leaves its timeout unset
+	defaultLoginTimeout = 30 * time.Second
+
+	// loginCheckWait is how long the logged in marker may take to show up before logging in again
+	loginCheckWait = 5 * time.Second
+
+	// sessionRestoredKey marks the tabs whose localStorage was already restored, so later navigations keep what the page wrote
+	sessionRestoredKey = "__crawler_session_restored"
+)
+
+// LoginSpec fills and submits a login form with credentials read from environment variables.
+//
+// The crawled page is checked for LoggedInSelector first, the form is only
+// filled when it is missing. The form is on Url, or on the crawled page itself
+// when Url is empty. Once the form is gone the crawled page is opened again and
+// LoggedInSelector must show up within Timeout.
+type LoginSpec struct {
+	Url              string
+	UsernameSelector string
+	PasswordSelector string
+	SubmitSelector   string
+	LoggedInSelector string
+
+	// UsernameEnv and PasswordEnv name the environment variables holding the credentials, they never appear in the config
+	UsernameEnv string
+	PasswordEnv string
+
+	Timeout time.Duration
+}
+
+// IsZero reports whether no login is configured
+func (spec LoginSpec) IsZero() bool {
+	return spec == LoginSpec{}
+}
+
+// SessionSpec declares how a setup keeps its browser session between crawls.
+//
+// With Persist the cookies and localStorage of the setup's pages are stored in
+// Postgres after every browser crawl and restored before the next one, so a
+// login is only needed when the session expired.
+type SessionSpec struct {
+	Persist bool
+	Login   LoginSpec
+}
+
+// IsZero reports whether the session is neither persisted nor logged in
+func (spec SessionSpec) IsZero() bool {
+	return !spec.Persist && spec.Login.IsZero()
+}
+
+// Validate checks the login selectors and that the credential environment variables are set
+func (spec SessionSpec) Validate() error {
+	login := spec.Login
+	if login.IsZero() {
+		return nil
+	}
+
+	if login.Url != "" {
+		loginUrl, err := url.Parse(login.Url)
+		if err != nil || (loginUrl.Scheme != "http" && loginUrl.Scheme != "https") {
+			return fmt.Errorf("invalid login url: %q", login.Url)
+		}
+	}
+
+	selectors := []struct {
+		name  string
+		value string
+	}{
+		{"username selector", login.UsernameSelector},
+		{"password selector", login.PasswordSelector},
+		{"submit selector", login.SubmitSelector},
+		{"logged in selector", login.LoggedInSelector},
+	}
+
+	for _, selector := range selectors {
+		if selector.value == "" {
+			return fmt.Errorf("login needs a %s", selector.name)
+		}
+
+		if _, err := cascadia.Compile(selector.value); err != nil {
+			return fmt.Errorf("invalid login %s: %w", selector.name, err)
+		}
+	}
+
+	for _, env := range []string{login.UsernameEnv, login.PasswordEnv} {
+		if env == "" {
+			return fmt.Errorf("login needs the username and password environment variables")
+		}
+
+		if os.Getenv(env) == "" {
+			return fmt.Errorf("login environment variable %s is not set", env)
+		}
+	}
+
+	if login.Timeout < 0 {
+		return fmt.Errorf("login timeout must not be negative")
+	}
+
+	return nil
+}
+
+// crawlSession is the browser state kept for a setup between crawls
+type crawlSession struct {
+	Cookies []*network.Cookie `json:"cookies"`
+
+	// LocalStorage holds the items of every origin visited by the setup's crawls
+	LocalStorage map[string]map[string]string `json:"local_storage"`
+}
+
+// restoreSession loads the setup's stored session into the tab, it must be called before navigating.
+// Restoring is best effort, a session that cannot be loaded only means logging in again
+func (service *Service) restoreSession(ctx context.Context, target *crawlTarget, logger *logrus.Entry) *crawlSession {
+	session := &crawlSession{
+		LocalStorage: map[string]map[string]string{},
+	}
+
+	if !target.Session.Persist {
+		return session
+	}
+
+	stored, err := service.store.GetCrawlSession(ctx, target.SetupId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return session
+	}
+
+	if err != nil {
+		logger.WithError(err).Warn("Failed to load browser session")
+
+		return session
+	}
+
+	err = json.Unmarshal(stored.Cookies, &session.Cookies)
+	if err == nil {
+		err = json.Unmarshal(stored.LocalStorage, &session.LocalStorage)
+	}
+
+	if err != nil || session.LocalStorage == nil {
+		logger.WithError(err).Warn("Failed to decode browser session, starting a new one")
+
+		return &crawlSession{
+			LocalStorage: map[string]map[string]string{},
+		}
+	}
+
+	// Expired cookies are left out, session cookies are kept like a browser left open would
+	now := time.Now()
+
+	var cookies []*network.CookieParam
+	for _, cookie := range session.Cookies {
+		param := &network.CookieParam{
+			Name:         cookie.Name,
+			Value:        cookie.Value,
+			Domain:       cookie.Domain,
+			Path:         cookie.Path,
+			Secure:       cookie.Secure,
+			HTTPOnly:     cookie.HTTPOnly,
+			SameSite:     cookie.SameSite,
+			Priority:     cookie.Priority,
+			SourceScheme: cookie.SourceScheme,
+			SourcePort:   cookie.SourcePort,
+			PartitionKey: cookie.PartitionKey,
+		}
+
+		if !cookie.Session {
+			expires := time.Unix(0, int64(cookie.Expires*float64(time.Second)))
+			if expires.Before(now) {
+				continue
+			}
+
+			param.Expires = (*cdp.TimeSinceEpoch)(&expires)
+		}
+
+		cookies = append(cookies, param)
+	}
+
+	localStorage, _ := json.Marshal(session.LocalStorage)
+
+	err = chromedp.Run(ctx,
+		network.SetCookies(cookies),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			_, err := page.AddScriptToEvaluateOnNewDocument(`(() => {
+				try {
+					const items = ` + string(localStorage) + `[location.origin];
+					if (!items || sessionStorage.getItem(` + jsString(sessionRestoredKey) + `)) return;
+					for (const [key, value] of Object.entries(items)) localStorage.setItem(key, value);
+					sessionStorage.setItem(` + jsString(sessionRestoredKey) + `, "1");
+				} catch (e) {}
+			})()`).Do(ctx)
+
+			return err
+		}),
+	)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to restore browser session")
+
+		return session
+	}
+
+	logger.WithFields(logrus.Fields{
+		"cookies":               len(cookies),
+		"local_storage_origins": len(session.LocalStorage),
+		"session_updated_at":    stored.UpdatedAt.Time.Format(time.RFC3339),
+	}).Info("Restored browser session")
+
+	return session
+}
+
+// saveSession stores the cookies and localStorage of the setup's pages for the next crawl.
+// Saving is best effort, failures are logged and never fail the crawl
+func (service *Service) saveSession(ctx context.Context, target *crawlTarget, session *crawlSession, logger *logrus.Entry) {
+	if !target.Session.Persist {
+		return
+	}
+
+	urls := []string{target.Url}
+	if target.Session.Login.Url != "" {
+		urls = append(urls, target.Session.Login.Url)
+	}
+
+	var cookies []*network.Cookie
+	var storage struct {
+		Origin string            `json:"origin"`
+		Items  map[string]string `json:"items"`
+	}
+
+	err := chromedp.Run(ctx,
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			cookies, err = network.GetCookies().WithURLs(urls).Do(ctx)
+
+			return err
+		}),
+		chromedp.Evaluate(`(() => {
+			try {
+				return { origin: location.origin, items: Object.fromEntries(Object.entries(localStorage)) };
+			} catch (e) {
+				return { origin: location.origin, items: {} };
+			}
+		})()`, &storage),
+	)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to read browser session")
+
+		return
+	}
+
+	session.Cookies = cookies
+	session.LocalStorage[storage.Origin] = storage.Items
+
+	encodedCookies, err := json.Marshal(session.Cookies)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to encode browser session")
+
+		return
+	}
+
+	encodedLocalStorage, err := json.Marshal(session.LocalStorage)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to encode browser session")
+
+		return
+	}
+
+	// The crawl context may run out right after, the session is still worth keeping
+	err = service.store.UpsertCrawlSession(context.WithoutCancel(ctx), sqlc.UpsertCrawlSessionParams{
+		SetupID:      target.SetupId,
+		Cookies:      encodedCookies,
+		LocalStorage: encodedLocalStorage,
+		UpdatedAt: pgtype.Timestamp{
+			Time:  time.Now(),
+			Valid: true,
+		},
+	})
+	if err != nil {
+		logger.WithError(err).Warn("Failed to store browser session")
+
+		return
+	}
+
+	logger.WithFields(logrus.Fields{
+		"cookies":               len(session.Cookies),
+		"local_storage_origins": len(session.LocalStorage),
+	}).Info("Stored browser session")
+}
+
+// logIn makes sure the crawled page is seen as a logged in member, filling the login form when the session expired.
+// The crawled page is opened again after logging in, its status code replaces the one of the first navigation
+func (service *Service) logIn(ctx context.Context, target *crawlTarget, session *crawlSession, fetched *fetchedPage, identity *crawlIdentity, logger *logrus.Entry) error {
+	login := target.Session.Login
+	if login.IsZero() {
+		return nil
+	}
+
+	startTime := time.Now()
+
+	loggedIn, err := waitVisible(ctx, login.LoggedInSelector, loginCheckWait)
+	if err != nil {
+		return fmt.Errorf("failed to check login: %w", err)
+	}
+
+	if loggedIn {
+		logger.Info("Session still logged in")
+
+		return nil
+	}
+
+	timeout := login.Timeout
+	if timeout <= 0 {
+		timeout = defaultLoginTimeout
+	}
+
+	loginCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if login.Url != "" {
+		err = service.waitPolitely(loginCtx, login.Url, identity)
+		if err == nil {
+			err = chromedp.Run(loginCtx, chromedp.Navigate(login.Url))
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to open login page: %w", err)
+		}
+	}
+
+	// Credentials are read at the last moment and never logged
+	err = chromedp.Run(loginCtx,
+		chromedp.WaitVisible(login.UsernameSelector, chromedp.ByQuery),
+		chromedp.SendKeys(login.UsernameSelector, os.Getenv(login.UsernameEnv), chromedp.ByQuery),
+		chromedp.SendKeys(login.PasswordSelector, os.Getenv(login.PasswordEnv), chromedp.ByQuery, chromedp.NodeVisible),
+		chromedp.Click(login.SubmitSelector, chromedp.ByQuery, chromedp.NodeVisible),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to fill login form: %w", err)
+	}
+
+	// The form is gone once the site took the credentials, whether it reloads the page or not
+	for {
+		visible, err := evaluateBool(loginCtx, selectorVisibleExpression(login.UsernameSelector))
+		if err != nil {
+			return fmt.Errorf("failed to submit login form: %w", err)
+		}
+
+		if !visible {
+			break
+		}
+
+		select {
+		case <-loginCtx.Done():
+			return fmt.Errorf("login form still shown after submitting: %w", loginCtx.Err())
+		case <-time.After(readinessPollInterval):
+		}
+	}
+
+	err = service.waitPolitely(loginCtx, target.Url, identity)
+	if err != nil {
+		return err
+	}
+
+	response, err := chromedp.RunResponse(loginCtx, chromedp.Navigate(target.Url))
+	if response != nil {
+		fetched.StatusCode = int(response.Status)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to open page after login: %w", err)
+	}
+
+	loggedIn, err = waitVisible(loginCtx, login.LoggedInSelector, timeout)
+	if err != nil {
+		return fmt.Errorf("failed to check login: %w", err)
+	}
+
+	if !loggedIn {
+		return fmt.Errorf("login failed: %q not visible after logging in", login.LoggedInSelector)
+	}
+
+	logger.WithField("login_duration_seconds", time.Since(startTime).Seconds()).Info("Logged in")
+
+	// A fresh login is kept right away, even when the rest of the crawl fails
+	service.saveSession(ctx, target, session, logger)
+
+	return nil
+}
+
+// waitVisible polls until an element matching the selector is visible, reporting false when it is not within the wait
+func waitVisible(ctx context.Context, selector string, wait time.Duration) (bool, error) {
+	deadline := time.Now().Add(wait)
+
+	for {
+		visible, err := evaluateBool(ctx, selectorVisibleExpression(selector))
+		if err != nil {
+			return false, err
+		}
+
+		if visible || !time.Now().Before(deadline) {
+			return visible, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(readinessPollInterval):
+		}
+	}
+}
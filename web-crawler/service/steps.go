@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/chromedp/chromedp"
+	"github.com/sirupsen/logrus"
+)
+
+// Browser step actions
+const (
+	StepNavigate = "navigate"
+	StepClick    = "click"
+	StepWait     = "wait"
+	StepSelect   = "select"
+	StepType     = "type"
+	StepScroll   = "scroll"
+	StepEvaluate = "evaluate"
+)
+
+// defaultStepTimeout bounds a step when it leaves its timeout unset
+const defaultStepTimeout = 10 * time.Second
+
+// BrowserStep is one action run in the browser between loading the page and extracting its prices.
+//
+// Selector is a CSS selector and Value depends on the action:
+//   - navigate: Value is the URL to open
+//   - click: clicks Selector
+//   - wait: waits for Selector to be visible, or for the Value duration (e.g. "2s")
+//   - select: picks the Value option of the Selector dropdown
+//   - type: types Value into Selector
+//   - scroll: scrolls Selector into view, or to the bottom of the page without a selector
+//   - evaluate: runs the Value JavaScript snippet
+type BrowserStep struct {
+	Action   string
+	Selector string
+	Value    string
+	Timeout  time.Duration
+}
+
+func (step BrowserStep) validate() error {
+	if step.Selector != "" {
+		if _, err := cascadia.Compile(step.Selector); err != nil {
+			return fmt.Errorf("invalid selector: %w", err)
+		}
+	}
+
+	if step.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+
+	switch step.Action {
+	case StepNavigate, StepEvaluate:
+		if step.Value == "" {
+			return fmt.Errorf("%s needs a value", step.Action)
+		}
+	case StepClick, StepSelect, StepType:
+		if step.Selector == "" {
+			return fmt.Errorf("%s needs a selector", step.Action)
+		}
+	case StepWait:
+		if step.Selector == "" {
+			if _, err := time.ParseDuration(step.Value); err != nil {
+				return fmt.Errorf("wait needs a selector or a duration value: %w", err)
+			}
+		}
+	case StepScroll:
+	default:
+		return fmt.Errorf("unsupported step action: %q", step.Action)
+	}
+
+	return nil
+}
+
+// ValidateSteps checks the action, selector and value of every step
+func ValidateSteps(steps []BrowserStep) error {
+	for i, step := range steps {
+		if err := step.validate(); err != nil {
+			return fmt.Errorf("invalid step #%d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// runSteps runs the setup's browser steps in order, stopping at the first failing one
+func (service *Service) runSteps(ctx context.Context, steps []BrowserStep, identity *crawlIdentity, logger *logrus.Entry) error {
+	for i, step := range steps {
+		logger := logger.WithFields(logrus.Fields{
+			"step":        i + 1,
+			"step_action": step.Action,
+		})
+
+		startTime := time.Now()
+
+		var err error
+
+		// Navigations fetch another page, so they are gated like the first one
+		if step.Action == StepNavigate {
+			err = service.waitPolitely(ctx, step.Value, identity)
+		}
+
+		if err == nil {
+			err = runStep(ctx, step)
+		}
+
+		logger = logger.WithField("step_duration_seconds", time.Since(startTime).Seconds())
+
+		if err != nil {
+			err = fmt.Errorf("step #%d (%s) failed: %w", i+1, step.Action, err)
+
+			logger.WithError(err).Error()
+
+			return err
+		}
+
+		logger.Info()
+	}
+
+	return nil
+}
+
+// runStep runs one browser step within its timeout
+func runStep(ctx context.Context, step BrowserStep) error {
+	timeout := step.Timeout
+	if timeout <= 0 {
+		timeout = defaultStepTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	switch step.Action {
+	case StepNavigate:
+		return chromedp.Run(ctx, chromedp.Navigate(step.Value))
+	case StepClick:
+		return chromedp.Run(ctx, chromedp.Click(step.Selector, chromedp.ByQuery, chromedp.NodeVisible))
+	case StepWait:
+		if step.Selector != "" {
+			return chromedp.Run(ctx, chromedp.WaitVisible(step.Selector, chromedp.ByQuery))
+		}
+
+		duration, err := time.ParseDuration(step.Value)
+		if err != nil {
+			return err
+		}
+
+		return chromedp.Run(ctx, chromedp.Sleep(duration))
+	case StepSelect:
+		// Setting the value alone does not trigger the page's handlers, so the events are dispatched too
+		return chromedp.Run(ctx,
+			chromedp.WaitVisible(step.Selector, chromedp.ByQuery),
+			chromedp.Evaluate(`(() => {
+				const element = document.querySelector(`+jsString(step.Selector)+`);
+				element.value = `+jsString(step.Value)+`;
+				element.dispatchEvent(new Event("input", { bubbles: true }));
+				element.dispatchEvent(new Event("change", { bubbles: true }));
+			})()`, nil),
+		)
+	case StepType:
+		return chromedp.Run(ctx, chromedp.SendKeys(step.Selector, step.Value, chromedp.ByQuery, chromedp.NodeVisible))
+	case StepScroll:
+		if step.Selector != "" {
+			return chromedp.Run(ctx, chromedp.ScrollIntoView(step.Selector, chromedp.ByQuery))
+		}
+
+		return chromedp.Run(ctx, chromedp.Evaluate(`window.scrollTo(0, document.body.scrollHeight)`, nil))
+	case StepEvaluate:
+		return chromedp.Run(ctx, chromedp.Evaluate(step.Value, nil))
+	default:
+		return fmt.Errorf("unsupported step action: %q", step.Action)
+	}
+}
+
+// jsString quotes a string as a JavaScript literal
+func jsString(value string) string {
+	quoted, _ := json.Marshal(value)
+
+	return string(quoted)
+}
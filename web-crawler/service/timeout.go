@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Crawl phases bounded by a timeout
+const (
+	// PhaseNavigation is the page request, up to the body being shown in a browser
+	PhaseNavigation = "navigation"
+	// PhaseExtraction is the read of the rendered page and the extraction of its prices
+	PhaseExtraction = "extraction"
+	// PhaseAttempt is a whole crawl attempt
+	PhaseAttempt = "attempt"
+	// PhaseJob is a whole scheduled job, every attempt and retry wait included
+	PhaseJob = "job"
+)
+
+const (
+	// DefaultAttemptTimeout bounds a crawl attempt when the spec leaves it unset
+	DefaultAttemptTimeout = 60 * time.Second
+
+	// DefaultJobTimeout bounds a job when the spec leaves it unset
+	DefaultJobTimeout = 15 * time.Minute
+)
+
+// TimeoutSpec bounds the phases of a setup's crawls.
+//
+// Navigation and Extraction left unset are only bounded by the attempt, Attempt
+// and Job fall back to the defaults. A phase never runs past the phase it is part of.
+type TimeoutSpec struct {
+	Navigation time.Duration
+	Extraction time.Duration
+	Attempt    time.Duration
+	Job        time.Duration
+}
+
+// Validate checks that no timeout is negative
+func (spec TimeoutSpec) Validate() error {
+	if spec.Navigation < 0 || spec.Extraction < 0 || spec.Attempt < 0 || spec.Job < 0 {
+		return fmt.Errorf("timeouts must not be negative")
+	}
+
+	return nil
+}
+
+func (spec TimeoutSpec) attempt() time.Duration {
+	if spec.Attempt > 0 {
+		return spec.Attempt
+	}
+
+	return DefaultAttemptTimeout
+}
+
+func (spec TimeoutSpec) job() time.Duration {
+	if spec.Job > 0 {
+		return spec.Job
+	}
+
+	return DefaultJobTimeout
+}
+
+// TimeoutError tells which phase of a crawl ran out of time, it matches context.DeadlineExceeded
+type TimeoutError struct {
+	Phase   string
+	Timeout time.Duration
+}
+
+func (err *TimeoutError) Error() string {
+	return fmt.Sprintf("%s timed out after %s", err.Phase, err.Timeout)
+}
+
+func (err *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// phaseDeadlineKey keeps the earliest phase deadline of a context, so that it can be carried over to browser tabs
+type phaseDeadlineKey struct{}
+
+type phaseDeadline struct {
+	deadline time.Time
+	cause    *TimeoutError
+}
+
+// withPhaseTimeout bounds the phase, a timeout of zero leaves it bounded by the phases it is part of
+func withPhaseTimeout(ctx context.Context, phase string, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	// The enclosing phase runs out first, it keeps the blame
+	current, found := ctx.Value(phaseDeadlineKey{}).(phaseDeadline)
+	if found && !deadline.Before(current.deadline) {
+		return context.WithCancel(ctx)
+	}
+
+	cause := &TimeoutError{Phase: phase, Timeout: timeout}
+
+	ctx = context.WithValue(ctx, phaseDeadlineKey{}, phaseDeadline{deadline: deadline, cause: cause})
+
+	return context.WithDeadlineCause(ctx, deadline, cause)
+}
+
+// withPhaseDeadlineOf derives a context from a browser tab that ends along with the crawl's context, with its phase deadline.
+// The tab itself is left open when the crawl's context ends, so that failure artifacts can still be captured from it
+func withPhaseDeadlineOf(tabCtx context.Context, ctx context.Context) (context.Context, context.CancelFunc) {
+	derivedCtx, cancel := context.WithCancelCause(tabCtx)
+
+	// Cancellations such as a shutdown are carried over with their cause, deadlines are copied below
+	stop := context.AfterFunc(ctx, func() {
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			cancel(context.Cause(ctx))
+		}
+	})
+
+	deadlineCancel := context.CancelFunc(func() {})
+	if deadline, found := ctx.Deadline(); found {
+		var cause error = context.DeadlineExceeded
+
+		if current, found := ctx.Value(phaseDeadlineKey{}).(phaseDeadline); found {
+			derivedCtx = context.WithValue(derivedCtx, phaseDeadlineKey{}, current)
+			cause = current.cause
+		}
+
+		derivedCtx, deadlineCancel = context.WithDeadlineCause(derivedCtx, deadline, cause)
+	}
+
+	return derivedCtx, func() {
+		stop()
+		deadlineCancel()
+		cancel(context.Canceled)
+	}
+}
+
+// phaseTimeout names the phase in the error when one of the contexts ran out of time, the first context telling why wins
+func phaseTimeout(err error, contexts ...context.Context) error {
+	var timeoutErr *TimeoutError
+	if err == nil || errors.As(err, &timeoutErr) {
+		return err
+	}
+
+	for _, ctx := range contexts {
+		if errors.As(context.Cause(ctx), &timeoutErr) {
+			return fmt.Errorf("%w: %w", timeoutErr, err)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,20 @@
+-- name: CreateCrawlArtifact :one
+INSERT INTO ibdwh.crawl_artifacts (setup_id, url, attempt, kind, content_type, content, error, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING artifact_id;
+
+-- name: GetAllCrawlArtifacts :many
+SELECT artifact_id, setup_id, url, attempt, kind, content_type, OCTET_LENGTH(content) AS size, error, created_at
+FROM ibdwh.crawl_artifacts
+WHERE (sqlc.narg('setup_id')::text IS NULL OR setup_id = sqlc.narg('setup_id')::text)
+ORDER BY created_at DESC, artifact_id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: GetTotalCrawlArtifacts :one
+SELECT COUNT(*) FROM ibdwh.crawl_artifacts
+WHERE (sqlc.narg('setup_id')::text IS NULL OR setup_id = sqlc.narg('setup_id')::text);
+
+-- name: GetCrawlArtifact :one
+SELECT * FROM ibdwh.crawl_artifacts
+WHERE artifact_id = $1;
\ No newline at end of file
@@ -0,0 +1,26 @@
+-- name: CreateCrawlAttempt :exec
+INSERT INTO ibdwh.crawl_attempts (setup_id, url, attempt, fetch_mode, outcome, error_class, error, jual_raw, beli_raw, candidates_raw, jual, beli, started_at, ended_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14);
+
+-- name: GetAllCrawlAttempts :many
+SELECT * FROM ibdwh.crawl_attempts
+WHERE (sqlc.narg('setup_id')::text IS NULL OR setup_id = sqlc.narg('setup_id')::text)
+ORDER BY started_at DESC, attempt_id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: GetTotalCrawlAttempts :one
+SELECT COUNT(*) FROM ibdwh.crawl_attempts
+WHERE (sqlc.narg('setup_id')::text IS NULL OR setup_id = sqlc.narg('setup_id')::text);
+
+-- name: GetCrawlAttemptFailureRates :many
+SELECT
+	attempt,
+	COUNT(*) AS attempts,
+	COUNT(*) FILTER (WHERE outcome = 'failure') AS failures
+FROM ibdwh.crawl_attempts
+WHERE (sqlc.narg('setup_id')::text IS NULL OR setup_id = sqlc.narg('setup_id')::text)
+	AND started_at >= sqlc.arg('since')
+	AND outcome <> 'skipped'
+GROUP BY attempt
+ORDER BY attempt;
\ No newline at end of file
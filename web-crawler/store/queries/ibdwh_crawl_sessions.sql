@@ -0,0 +1,11 @@
+-- name: GetCrawlSession :one
+SELECT * FROM ibdwh.crawl_sessions
+WHERE setup_id = $1;
+
+-- name: UpsertCrawlSession :exec
+INSERT INTO ibdwh.crawl_sessions (setup_id, cookies, local_storage, updated_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (setup_id) DO UPDATE SET
+	cookies = EXCLUDED.cookies,
+	local_storage = EXCLUDED.local_storage,
+	updated_at = EXCLUDED.updated_at;
\ No newline at end of file
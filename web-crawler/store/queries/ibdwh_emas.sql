@@ -1,13 +1,3 @@
--- name: CreateEmas :one
-INSERT INTO ibdwh.emas (emas_id, jual, beli, created_at)
-VALUES ($1, $2, $3, $4)
-ON CONFLICT (emas_id) 
-DO UPDATE SET 
-    jual = EXCLUDED.jual,
-    beli = EXCLUDED.beli,
-    created_at = EXCLUDED.created_at
-RETURNING *;
-
 -- name: GetAllEmas :many
 SELECT * FROM ibdwh.emas
 ORDER BY emas_id DESC
@@ -15,4 +5,22 @@ LIMIT $1
 OFFSET $2;
 
 -- name: GetTotalEmas :one
-SELECT COUNT(*) FROM ibdwh.emas;
\ No newline at end of file
+SELECT COUNT(*) FROM ibdwh.emas;
+
+-- name: RollupEmas :one
+INSERT INTO ibdwh.emas (emas_id, jual, beli, created_at)
+SELECT TO_CHAR(latest.observed_at, 'YYYY-MM-DD'), latest.jual, latest.beli, latest.observed_at
+FROM (
+    SELECT jual, beli, observed_at FROM ibdwh.price_observations
+    WHERE setup_id = @setup_id
+    AND observed_at >= @day::date
+    AND observed_at < @day::date + 1
+    ORDER BY observed_at DESC, observation_id DESC
+    LIMIT 1
+) latest
+ON CONFLICT (emas_id) 
+DO UPDATE SET 
+    jual = EXCLUDED.jual,
+    beli = EXCLUDED.beli,
+    created_at = EXCLUDED.created_at
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,51 @@
+-- name: GetAllEmasOhlc :many
+SELECT * FROM ibdwh.emas_ohlc
+WHERE (sqlc.narg('setup_id')::text IS NULL OR setup_id = sqlc.narg('setup_id')::text)
+ORDER BY ohlc_date DESC, setup_id
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: GetTotalEmasOhlc :one
+SELECT COUNT(*) FROM ibdwh.emas_ohlc
+WHERE (sqlc.narg('setup_id')::text IS NULL OR setup_id = sqlc.narg('setup_id')::text);
+
+-- name: RollupEmasOhlc :one
+INSERT INTO ibdwh.emas_ohlc (
+    setup_id, ohlc_date,
+    jual_open, jual_high, jual_low, jual_close,
+    beli_open, beli_high, beli_low, beli_close,
+    spread_avg, observation_count, updated_at
+)
+SELECT
+    @setup_id,
+    @day::date,
+    (ARRAY_AGG(jual ORDER BY observed_at, observation_id))[1],
+    MAX(jual),
+    MIN(jual),
+    (ARRAY_AGG(jual ORDER BY observed_at DESC, observation_id DESC))[1],
+    (ARRAY_AGG(beli ORDER BY observed_at, observation_id))[1],
+    MAX(beli),
+    MIN(beli),
+    (ARRAY_AGG(beli ORDER BY observed_at DESC, observation_id DESC))[1],
+    ROUND(AVG(jual - beli), 2),
+    COUNT(*),
+    NOW()
+FROM ibdwh.price_observations
+WHERE setup_id = @setup_id
+AND observed_at >= @day::date
+AND observed_at < @day::date + 1
+HAVING COUNT(*) > 0
+ON CONFLICT (setup_id, ohlc_date) 
+DO UPDATE SET 
+    jual_open = EXCLUDED.jual_open,
+    jual_high = EXCLUDED.jual_high,
+    jual_low = EXCLUDED.jual_low,
+    jual_close = EXCLUDED.jual_close,
+    beli_open = EXCLUDED.beli_open,
+    beli_high = EXCLUDED.beli_high,
+    beli_low = EXCLUDED.beli_low,
+    beli_close = EXCLUDED.beli_close,
+    spread_avg = EXCLUDED.spread_avg,
+    observation_count = EXCLUDED.observation_count,
+    updated_at = EXCLUDED.updated_at
+RETURNING *;
\ No newline at end of file
@@ -0,0 +1,23 @@
+-- name: CreatePageSnapshot :one
+INSERT INTO ibdwh.page_snapshots (setup_id, url, attempt, fetch_mode, status_code, error, error_class, proxy, user_agent, content, captured_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING snapshot_id;
+
+-- name: DeletePageSnapshotsBefore :execrows
+DELETE FROM ibdwh.page_snapshots
+WHERE captured_at < @before;
+
+-- name: DeletePageSnapshotsOverLimit :execrows
+DELETE FROM ibdwh.page_snapshots
+WHERE snapshot_id NOT IN (
+    SELECT snapshot_id FROM ibdwh.page_snapshots
+    ORDER BY captured_at DESC, snapshot_id DESC
+    LIMIT @keep
+);
+
+-- name: ListPageSnapshots :many
+SELECT * FROM ibdwh.page_snapshots
+WHERE (sqlc.narg('setup_id')::text IS NULL OR setup_id = sqlc.narg('setup_id')::text)
+AND (sqlc.narg('from')::timestamp IS NULL OR captured_at >= sqlc.narg('from')::timestamp)
+AND (sqlc.narg('to')::timestamp IS NULL OR captured_at < sqlc.narg('to')::timestamp)
+ORDER BY captured_at, snapshot_id;
\ No newline at end of file
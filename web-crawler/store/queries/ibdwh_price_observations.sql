@@ -0,0 +1,18 @@
+-- name: CreatePriceObservation :one
+INSERT INTO ibdwh.price_observations (setup_id, url, jual, beli, jual_label, beli_label, observed_at, captured_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: DeletePriceObservationsByCapture :many
+DELETE FROM ibdwh.price_observations
+WHERE setup_id = @setup_id AND captured_at = @captured_at
+RETURNING *;
+
+-- name: GetAllPriceObservations :many
+SELECT * FROM ibdwh.price_observations
+ORDER BY observed_at DESC, observation_id DESC
+LIMIT $1
+OFFSET $2;
+
+-- name: GetTotalPriceObservations :one
+SELECT COUNT(*) FROM ibdwh.price_observations;
\ No newline at end of file
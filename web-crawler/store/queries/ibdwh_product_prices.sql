@@ -0,0 +1,17 @@
+-- name: CreateProductPrice :one
+INSERT INTO ibdwh.product_prices (observation_id, product, weight_grams, jual, beli, observed_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetAllProductPrices :many
+SELECT * FROM ibdwh.product_prices
+WHERE (sqlc.narg('product')::text IS NULL OR LOWER(product) = LOWER(sqlc.narg('product')::text))
+AND (sqlc.narg('weight_grams')::numeric IS NULL OR weight_grams = sqlc.narg('weight_grams')::numeric)
+ORDER BY observed_at DESC, product, weight_grams
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: GetTotalProductPrices :one
+SELECT COUNT(*) FROM ibdwh.product_prices
+WHERE (sqlc.narg('product')::text IS NULL OR LOWER(product) = LOWER(sqlc.narg('product')::text))
+AND (sqlc.narg('weight_grams')::numeric IS NULL OR weight_grams = sqlc.narg('weight_grams')::numeric);
\ No newline at end of file
@@ -11,42 +11,6 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const createEmas = `-- name: CreateEmas :one
-INSERT INTO ibdwh.emas (emas_id, jual, beli, created_at)
-VALUES ($1, $2, $3, $4)
-ON CONFLICT (emas_id) 
-DO UPDATE SET 
-    jual = EXCLUDED.jual,
-    beli = EXCLUDED.beli,
-    created_at = EXCLUDED.created_at
-RETURNING emas_id, jual, beli, created_at, avg_bpkh
-`
-
-type CreateEmasParams struct {
-	EmasID    string           `json:"emas_id"`
-	Jual      pgtype.Numeric   `json:"jual"`
-	Beli      pgtype.Numeric   `json:"beli"`
-	CreatedAt pgtype.Timestamp `json:"created_at"`
-}
-
-func (q *Queries) CreateEmas(ctx context.Context, arg CreateEmasParams) (IbdwhEma, error) {
-	row := q.db.QueryRow(ctx, createEmas,
-		arg.EmasID,
-		arg.Jual,
-		arg.Beli,
-		arg.CreatedAt,
-	)
-	var i IbdwhEma
-	err := row.Scan(
-		&i.EmasID,
-		&i.Jual,
-		&i.Beli,
-		&i.CreatedAt,
-		&i.AvgBpkh,
-	)
-	return i, err
-}
-
 const getAllEmas = `-- name: GetAllEmas :many
 SELECT emas_id, jual, beli, created_at, avg_bpkh FROM ibdwh.emas
 ORDER BY emas_id DESC
@@ -95,3 +59,40 @@ func (q *Queries) GetTotalEmas(ctx context.Context) (int64, error) {
 	err := row.Scan(&count)
 	return count, err
 }
+
+const rollupEmas = `-- name: RollupEmas :one
+INSERT INTO ibdwh.emas (emas_id, jual, beli, created_at)
+SELECT TO_CHAR(latest.observed_at, 'YYYY-MM-DD'), latest.jual, latest.beli, latest.observed_at
+FROM (
+    SELECT jual, beli, observed_at FROM ibdwh.price_observations
+    WHERE setup_id = $1
+    AND observed_at >= $2::date
+    AND observed_at < $2::date + 1
+    ORDER BY observed_at DESC, observation_id DESC
+    LIMIT 1
+) latest
+ON CONFLICT (emas_id) 
+DO UPDATE SET 
+    jual = EXCLUDED.jual,
+    beli = EXCLUDED.beli,
+    created_at = EXCLUDED.created_at
+RETURNING emas_id, jual, beli, created_at, avg_bpkh
+`
+
+type RollupEmasParams struct {
+	SetupID string      `json:"setup_id"`
+	Day     pgtype.Date `json:"day"`
+}
+
+func (q *Queries) RollupEmas(ctx context.Context, arg RollupEmasParams) (IbdwhEma, error) {
+	row := q.db.QueryRow(ctx, rollupEmas, arg.SetupID, arg.Day)
+	var i IbdwhEma
+	err := row.Scan(
+		&i.EmasID,
+		&i.Jual,
+		&i.Beli,
+		&i.CreatedAt,
+		&i.AvgBpkh,
+	)
+	return i, err
+}
@@ -6,12 +6,103 @@ package sqlc
 
 import (
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
 )
 
+type IbdwhCrawlArtifact struct {
+	ArtifactID  int64            `json:"artifact_id"`
+	SetupID     string           `json:"setup_id"`
+	Url         string           `json:"url"`
+	Attempt     int32            `json:"attempt"`
+	Kind        string           `json:"kind"`
+	ContentType string           `json:"content_type"`
+	Content     []byte           `json:"content"`
+	Error       string           `json:"error"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+}
+
+type IbdwhCrawlAttempt struct {
+	AttemptID     int64               `json:"attempt_id"`
+	SetupID       string              `json:"setup_id"`
+	Url           string              `json:"url"`
+	Attempt       int32               `json:"attempt"`
+	FetchMode     pgtype.Text         `json:"fetch_mode"`
+	Outcome       string              `json:"outcome"`
+	ErrorClass    pgtype.Text         `json:"error_class"`
+	Error         pgtype.Text         `json:"error"`
+	JualRaw       pgtype.Text         `json:"jual_raw"`
+	BeliRaw       pgtype.Text         `json:"beli_raw"`
+	CandidatesRaw []string            `json:"candidates_raw"`
+	Jual          decimal.NullDecimal `json:"jual"`
+	Beli          decimal.NullDecimal `json:"beli"`
+	StartedAt     pgtype.Timestamp    `json:"started_at"`
+	EndedAt       pgtype.Timestamp    `json:"ended_at"`
+}
+
+type IbdwhCrawlSession struct {
+	SetupID      string           `json:"setup_id"`
+	Cookies      []byte           `json:"cookies"`
+	LocalStorage []byte           `json:"local_storage"`
+	UpdatedAt    pgtype.Timestamp `json:"updated_at"`
+}
+
 type IbdwhEma struct {
-	EmasID    string           `json:"emas_id"`
-	Jual      pgtype.Numeric   `json:"jual"`
-	Beli      pgtype.Numeric   `json:"beli"`
-	CreatedAt pgtype.Timestamp `json:"created_at"`
-	AvgBpkh   pgtype.Numeric   `json:"avg_bpkh"`
+	EmasID    string              `json:"emas_id"`
+	Jual      decimal.NullDecimal `json:"jual"`
+	Beli      decimal.NullDecimal `json:"beli"`
+	CreatedAt pgtype.Timestamp    `json:"created_at"`
+	AvgBpkh   decimal.NullDecimal `json:"avg_bpkh"`
+}
+
+type IbdwhEmasOhlc struct {
+	SetupID          string           `json:"setup_id"`
+	OhlcDate         pgtype.Date      `json:"ohlc_date"`
+	JualOpen         decimal.Decimal  `json:"jual_open"`
+	JualHigh         decimal.Decimal  `json:"jual_high"`
+	JualLow          decimal.Decimal  `json:"jual_low"`
+	JualClose        decimal.Decimal  `json:"jual_close"`
+	BeliOpen         decimal.Decimal  `json:"beli_open"`
+	BeliHigh         decimal.Decimal  `json:"beli_high"`
+	BeliLow          decimal.Decimal  `json:"beli_low"`
+	BeliClose        decimal.Decimal  `json:"beli_close"`
+	SpreadAvg        decimal.Decimal  `json:"spread_avg"`
+	ObservationCount int32            `json:"observation_count"`
+	UpdatedAt        pgtype.Timestamp `json:"updated_at"`
+}
+
+type IbdwhPageSnapshot struct {
+	SnapshotID int64            `json:"snapshot_id"`
+	SetupID    string           `json:"setup_id"`
+	Url        string           `json:"url"`
+	Attempt    int32            `json:"attempt"`
+	FetchMode  string           `json:"fetch_mode"`
+	StatusCode pgtype.Int4      `json:"status_code"`
+	Error      pgtype.Text      `json:"error"`
+	ErrorClass pgtype.Text      `json:"error_class"`
+	Proxy      pgtype.Text      `json:"proxy"`
+	UserAgent  pgtype.Text      `json:"user_agent"`
+	Content    []byte           `json:"content"`
+	CapturedAt pgtype.Timestamp `json:"captured_at"`
+}
+
+type IbdwhPriceObservation struct {
+	ObservationID int64            `json:"observation_id"`
+	SetupID       string           `json:"setup_id"`
+	Url           string           `json:"url"`
+	Jual          decimal.Decimal  `json:"jual"`
+	Beli          decimal.Decimal  `json:"beli"`
+	JualLabel     pgtype.Text      `json:"jual_label"`
+	BeliLabel     pgtype.Text      `json:"beli_label"`
+	ObservedAt    pgtype.Timestamp `json:"observed_at"`
+	CapturedAt    pgtype.Timestamp `json:"captured_at"`
+}
+
+type IbdwhProductPrice struct {
+	ProductPriceID int64               `json:"product_price_id"`
+	ObservationID  int64               `json:"observation_id"`
+	Product        string              `json:"product"`
+	WeightGrams    decimal.Decimal     `json:"weight_grams"`
+	Jual           decimal.Decimal     `json:"jual"`
+	Beli           decimal.NullDecimal `json:"beli"`
+	ObservedAt     pgtype.Timestamp    `json:"observed_at"`
 }
@@ -6,12 +6,38 @@ package sqlc
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
-	CreateEmas(ctx context.Context, arg CreateEmasParams) (IbdwhEma, error)
+	CreateCrawlArtifact(ctx context.Context, arg CreateCrawlArtifactParams) (int64, error)
+	CreateCrawlAttempt(ctx context.Context, arg CreateCrawlAttemptParams) error
+	CreatePageSnapshot(ctx context.Context, arg CreatePageSnapshotParams) (int64, error)
+	CreatePriceObservation(ctx context.Context, arg CreatePriceObservationParams) (IbdwhPriceObservation, error)
+	CreateProductPrice(ctx context.Context, arg CreateProductPriceParams) (IbdwhProductPrice, error)
+	DeletePageSnapshotsBefore(ctx context.Context, before pgtype.Timestamp) (int64, error)
+	DeletePageSnapshotsOverLimit(ctx context.Context, keep int32) (int64, error)
+	DeletePriceObservationsByCapture(ctx context.Context, arg DeletePriceObservationsByCaptureParams) ([]IbdwhPriceObservation, error)
+	GetAllCrawlArtifacts(ctx context.Context, arg GetAllCrawlArtifactsParams) ([]GetAllCrawlArtifactsRow, error)
+	GetAllCrawlAttempts(ctx context.Context, arg GetAllCrawlAttemptsParams) ([]IbdwhCrawlAttempt, error)
 	GetAllEmas(ctx context.Context, arg GetAllEmasParams) ([]IbdwhEma, error)
+	GetAllEmasOhlc(ctx context.Context, arg GetAllEmasOhlcParams) ([]IbdwhEmasOhlc, error)
+	GetAllPriceObservations(ctx context.Context, arg GetAllPriceObservationsParams) ([]IbdwhPriceObservation, error)
+	GetAllProductPrices(ctx context.Context, arg GetAllProductPricesParams) ([]IbdwhProductPrice, error)
+	GetCrawlArtifact(ctx context.Context, artifactID int64) (IbdwhCrawlArtifact, error)
+	GetCrawlAttemptFailureRates(ctx context.Context, arg GetCrawlAttemptFailureRatesParams) ([]GetCrawlAttemptFailureRatesRow, error)
+	GetCrawlSession(ctx context.Context, setupID string) (IbdwhCrawlSession, error)
+	GetTotalCrawlArtifacts(ctx context.Context, setupID pgtype.Text) (int64, error)
+	GetTotalCrawlAttempts(ctx context.Context, setupID pgtype.Text) (int64, error)
 	GetTotalEmas(ctx context.Context) (int64, error)
+	GetTotalEmasOhlc(ctx context.Context, setupID pgtype.Text) (int64, error)
+	GetTotalPriceObservations(ctx context.Context) (int64, error)
+	GetTotalProductPrices(ctx context.Context, arg GetTotalProductPricesParams) (int64, error)
+	ListPageSnapshots(ctx context.Context, arg ListPageSnapshotsParams) ([]IbdwhPageSnapshot, error)
+	RollupEmas(ctx context.Context, arg RollupEmasParams) (IbdwhEma, error)
+	RollupEmasOhlc(ctx context.Context, arg RollupEmasOhlcParams) (IbdwhEmasOhlc, error)
+	UpsertCrawlSession(ctx context.Context, arg UpsertCrawlSessionParams) error
 }
 
 var _ Querier = (*Queries)(nil)
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"sync"
 
 	"web-crawler/store/sqlc"
@@ -11,6 +12,8 @@ import (
 
 type IStore interface {
 	sqlc.Querier
+
+	WithTx(ctx context.Context, fn func(*sqlc.Queries) error) error
 }
 
 type Store struct {
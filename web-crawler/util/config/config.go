@@ -8,9 +8,14 @@ import (
 
 // Config holds all configuration for the application
 type Config struct {
-	App       App       `mapstructure:"app"`
-	DB        DB        `mapstructure:"db"`
-	Scheduler Scheduler `mapstructure:"scheduler"`
+	App        App        `mapstructure:"app"`
+	DB         DB         `mapstructure:"db"`
+	Scheduler  Scheduler  `mapstructure:"scheduler"`
+	Archive    Archive    `mapstructure:"archive"`
+	Browser    Browser    `mapstructure:"browser"`
+	Politeness Politeness `mapstructure:"politeness"`
+
+	CircuitBreaker CircuitBreaker `mapstructure:"circuit_breaker"`
 }
 
 // LoadConfig reads configuration from file or environment variables.
@@ -31,12 +31,21 @@ type DB struct {
 // Scheduler config
 
 type SchedulerSetup struct {
-	Id             string        `mapstructure:"id"`
-	Url            string        `mapstructure:"url"`
-	StartTime      string        `mapstructure:"start_time"`
-	TickerDuration time.Duration `mapstructure:"ticker_duration"`
-	Timezone       string        `mapstructure:"timezone"`
-	Retry          RetryConfig   `mapstructure:"retry"`
+	Id             string           `mapstructure:"id"`
+	Url            string           `mapstructure:"url"`
+	StartTime      string           `mapstructure:"start_time"`
+	TickerDuration time.Duration    `mapstructure:"ticker_duration"`
+	Timezone       string           `mapstructure:"timezone"`
+	Retry          RetryConfig      `mapstructure:"retry"`
+	Extraction     ExtractionConfig `mapstructure:"extraction"`
+	FetchMode      string           `mapstructure:"fetch_mode"`
+	Readiness      ReadinessConfig  `mapstructure:"readiness"`
+	Blocking       BlockingConfig   `mapstructure:"blocking"`
+	Rotation       RotationConfig   `mapstructure:"rotation"`
+	Steps          []StepConfig     `mapstructure:"steps"`
+	Session        SessionConfig    `mapstructure:"session"`
+	Emulation      EmulationConfig  `mapstructure:"emulation"`
+	Timeouts       TimeoutConfig    `mapstructure:"timeouts"`
 }
 
 type RetryConfig struct {
@@ -45,8 +54,160 @@ type RetryConfig struct {
 	MaxDelay      time.Duration `mapstructure:"max_delay"`
 	BackoffFactor float64       `mapstructure:"backoff_factor"`
 	EnableJitter  bool          `mapstructure:"enable_jitter"`
+	Strategy      string        `mapstructure:"strategy"`
+
+	IgnoreRetryAfter bool          `mapstructure:"ignore_retry_after"`
+	MaxRetryAfter    time.Duration `mapstructure:"max_retry_after"`
+
+	Policies map[string]RetryPolicyConfig `mapstructure:"policies"`
+}
+
+type RetryPolicyConfig struct {
+	MaxAttempts  int           `mapstructure:"max_attempts"`
+	InitialDelay time.Duration `mapstructure:"initial_delay"`
+}
+
+type ReadinessConfig struct {
+	Selector    string        `mapstructure:"selector"`
+	Text        string        `mapstructure:"text"`
+	NetworkIdle time.Duration `mapstructure:"network_idle"`
+	Js          string        `mapstructure:"js"`
+	MaxWait     time.Duration `mapstructure:"max_wait"`
+}
+
+type BlockingConfig struct {
+	ResourceTypes []string `mapstructure:"resource_types"`
+	Domains       []string `mapstructure:"domains"`
+}
+
+type RotationConfig struct {
+	Proxies     []string      `mapstructure:"proxies"`
+	UserAgents  []string      `mapstructure:"user_agents"`
+	Strategy    string        `mapstructure:"strategy"`
+	MaxFailures int           `mapstructure:"max_failures"`
+	Cooldown    time.Duration `mapstructure:"cooldown"`
+}
+
+type StepConfig struct {
+	Action   string        `mapstructure:"action"`
+	Selector string        `mapstructure:"selector"`
+	Value    string        `mapstructure:"value"`
+	Timeout  time.Duration `mapstructure:"timeout"`
+}
+
+type SessionConfig struct {
+	Persist bool        `mapstructure:"persist"`
+	Login   LoginConfig `mapstructure:"login"`
+}
+
+type LoginConfig struct {
+	Url              string        `mapstructure:"url"`
+	UsernameSelector string        `mapstructure:"username_selector"`
+	PasswordSelector string        `mapstructure:"password_selector"`
+	SubmitSelector   string        `mapstructure:"submit_selector"`
+	LoggedInSelector string        `mapstructure:"logged_in_selector"`
+	UsernameEnv      string        `mapstructure:"username_env"`
+	PasswordEnv      string        `mapstructure:"password_env"`
+	Timeout          time.Duration `mapstructure:"timeout"`
+}
+
+type EmulationConfig struct {
+	Timezone       string             `mapstructure:"timezone"`
+	AcceptLanguage string             `mapstructure:"accept_language"`
+	Geolocation    *GeolocationConfig `mapstructure:"geolocation"`
+	Device         string             `mapstructure:"device"`
+	Width          int64              `mapstructure:"width"`
+	Height         int64              `mapstructure:"height"`
+	Scale          float64            `mapstructure:"scale"`
+}
+
+type GeolocationConfig struct {
+	Latitude  float64 `mapstructure:"latitude"`
+	Longitude float64 `mapstructure:"longitude"`
+	Accuracy  float64 `mapstructure:"accuracy"`
+}
+
+type TimeoutConfig struct {
+	Navigation time.Duration `mapstructure:"navigation"`
+	Extraction time.Duration `mapstructure:"extraction"`
+	Attempt    time.Duration `mapstructure:"attempt"`
+	Job        time.Duration `mapstructure:"job"`
+}
+
+type ExtractionRule struct {
+	Type       string `mapstructure:"type"`
+	Expression string `mapstructure:"expression"`
+	Pattern    string `mapstructure:"pattern"`
+}
+
+type ExtractionLabelsConfig struct {
+	Jual     []string `mapstructure:"jual"`
+	Beli     []string `mapstructure:"beli"`
+	Pattern  string   `mapstructure:"pattern"`
+	MaxDepth int      `mapstructure:"max_depth"`
+}
+
+type ExtractionProductConfig struct {
+	Name    string           `mapstructure:"name"`
+	Labels  []string         `mapstructure:"labels"`
+	Rules   []ExtractionRule `mapstructure:"rules"`
+	Pattern string           `mapstructure:"pattern"`
+}
+
+type ExtractionConfig struct {
+	Labels         ExtractionLabelsConfig    `mapstructure:"labels"`
+	Jual           []ExtractionRule          `mapstructure:"jual"`
+	Beli           []ExtractionRule          `mapstructure:"beli"`
+	Prices         []ExtractionRule          `mapstructure:"prices"`
+	UnitMultiplier float64                   `mapstructure:"unit_multiplier"`
+	Locale         string                    `mapstructure:"locale"`
+	Product        string                    `mapstructure:"product"`
+	Products       []ExtractionProductConfig `mapstructure:"products"`
 }
 
 type Scheduler struct {
 	Setups []SchedulerSetup `mapstructure:"setups"`
 }
+
+// Archive config
+
+type ArchiveRetention struct {
+	MaxAge       time.Duration `mapstructure:"max_age"`
+	MaxSnapshots int           `mapstructure:"max_snapshots"`
+}
+
+type Archive struct {
+	Type      string           `mapstructure:"type"`
+	Directory string           `mapstructure:"directory"`
+	Retention ArchiveRetention `mapstructure:"retention"`
+}
+
+// Browser config
+
+type Browser struct {
+	Browsers            int           `mapstructure:"browsers"`
+	MaxTabs             int           `mapstructure:"max_tabs"`
+	MaxUses             int           `mapstructure:"max_uses"`
+	RemoteUrl           string        `mapstructure:"remote_url"`
+	HealthCheckInterval time.Duration `mapstructure:"health_check_interval"`
+	StartTimeout        time.Duration `mapstructure:"start_timeout"`
+}
+
+// Politeness config
+
+type Politeness struct {
+	IgnoreRobots      bool          `mapstructure:"ignore_robots"`
+	UserAgent         string        `mapstructure:"user_agent"`
+	RequestsPerMinute float64       `mapstructure:"requests_per_minute"`
+	Burst             int           `mapstructure:"burst"`
+	RobotsTTL         time.Duration `mapstructure:"robots_ttl"`
+	MaxWait           time.Duration `mapstructure:"max_wait"`
+}
+
+// Circuit breaker config
+
+type CircuitBreaker struct {
+	Disabled         bool          `mapstructure:"disabled"`
+	FailureThreshold int           `mapstructure:"failure_threshold"`
+	Cooldown         time.Duration `mapstructure:"cooldown"`
+}
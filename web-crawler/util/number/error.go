@@ -0,0 +1,38 @@
+package number
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrEmpty is returned when the text holds no number at all
+	ErrEmpty = errors.New("no number found")
+
+	// ErrInvalidNumber is returned when the number is malformed, e.g. has several decimal separators
+	ErrInvalidNumber = errors.New("invalid number")
+
+	// ErrInvalidGrouping is returned when thousands separators do not split the digits in valid groups
+	ErrInvalidGrouping = errors.New("invalid digit grouping")
+
+	// ErrUnexpectedText is returned when the number is followed by something that is not a unit or currency
+	ErrUnexpectedText = errors.New("unexpected text after number")
+
+	// ErrUnknownLocale is returned when a locale tag is not supported
+	ErrUnknownLocale = errors.New("unknown locale")
+)
+
+// ParseError describes why a text could not be parsed as a number
+type ParseError struct {
+	Input  string
+	Locale string
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("failed to parse %q as %s number: %s", e.Input, e.Locale, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
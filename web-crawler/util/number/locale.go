@@ -0,0 +1,78 @@
+package number
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Locale describes how numbers are written in a locale
+type Locale struct {
+	// Tag is the BCP 47 tag of the locale, e.g. "id-ID"
+	Tag string
+
+	// Decimal is the decimal separator
+	Decimal rune
+
+	// Groups lists every rune accepted as thousands separator
+	Groups []rune
+
+	// IndianGrouping accepts groups of two digits before the last group of three, e.g. "1,00,000"
+	IndianGrouping bool
+}
+
+var locales = map[string]Locale{}
+
+func init() {
+	for _, locale := range []Locale{
+		{Tag: "id-ID", Decimal: ',', Groups: []rune{'.'}},
+		{Tag: "en-US", Decimal: '.', Groups: []rune{','}},
+		{Tag: "en-GB", Decimal: '.', Groups: []rune{','}},
+		{Tag: "en-SG", Decimal: '.', Groups: []rune{','}},
+		{Tag: "en-IN", Decimal: '.', Groups: []rune{','}, IndianGrouping: true},
+		{Tag: "ms-MY", Decimal: '.', Groups: []rune{','}},
+		{Tag: "ja-JP", Decimal: '.', Groups: []rune{','}},
+		{Tag: "zh-CN", Decimal: '.', Groups: []rune{','}},
+		{Tag: "de-DE", Decimal: ',', Groups: []rune{'.'}},
+		{Tag: "nl-NL", Decimal: ',', Groups: []rune{'.'}},
+		{Tag: "es-ES", Decimal: ',', Groups: []rune{'.'}},
+		{Tag: "it-IT", Decimal: ',', Groups: []rune{'.'}},
+		{Tag: "pt-BR", Decimal: ',', Groups: []rune{'.'}},
+		{Tag: "fr-FR", Decimal: ',', Groups: []rune{' '}},
+		{Tag: "de-CH", Decimal: '.', Groups: []rune{'\'', '’'}},
+	} {
+		locales[strings.ToLower(locale.Tag)] = locale
+	}
+}
+
+// LookupLocale returns the locale for a tag such as "id-ID" or "en_US", ignoring case
+func LookupLocale(tag string) (Locale, error) {
+	locale, ok := locales[strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))]
+	if !ok {
+		return Locale{}, fmt.Errorf("%w: %q", ErrUnknownLocale, tag)
+	}
+
+	return locale, nil
+}
+
+// Locales returns the tags of every supported locale
+func Locales() []string {
+	tags := make([]string, 0, len(locales))
+	for _, locale := range locales {
+		tags = append(tags, locale.Tag)
+	}
+
+	sort.Strings(tags)
+
+	return tags
+}
+
+func (locale Locale) isGroup(r rune) bool {
+	for _, group := range locale.Groups {
+		if r == group {
+			return true
+		}
+	}
+
+	return false
+}
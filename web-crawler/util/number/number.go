@@ -0,0 +1,240 @@
+package number
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/shopspring/decimal"
+)
+
+// currencies lists the currency codes and symbols stripped in front of amounts, longest first
+var currencies = []string{
+	"IDR", "USD", "EUR", "SGD", "MYR", "GBP", "JPY", "INR", "CHF",
+	"US$", "S$", "Rp", "RM", "Fr",
+	"$", "€", "£", "¥", "₹",
+}
+
+// spaces lists the space variants replaced by a regular space before parsing
+var spaces = strings.NewReplacer(
+	"\u00a0", " ", // non-breaking space
+	"\u202f", " ", // narrow non-breaking space
+	"\u2007", " ", // figure space
+	"\u2009", " ", // thin space
+	"\t", " ",
+)
+
+// Parse parses a currency amount written in the given locale.
+//
+// The amount may be preceded by a currency code or symbol ("Rp", "Rp.", "IDR",
+// "$", ...) and followed by a unit or currency suffix ("/ 0,01 gr", "per
+// gram", "IDR", ",-"), which are ignored. Any kind of space is accepted.
+func Parse(text string, locale Locale) (decimal.Decimal, error) {
+	fail := func(err error) (decimal.Decimal, error) {
+		return decimal.Zero, &ParseError{
+			Input:  text,
+			Locale: locale.Tag,
+			Err:    err,
+		}
+	}
+
+	rest := normalizeSpaces(text)
+
+	// Sign may come before or after the currency
+	rest, negative := trimSign(rest)
+	rest = trimCurrency(rest)
+	if !negative {
+		rest, negative = trimSign(rest)
+	}
+
+	token, rest := scanNumber(rest, locale)
+	if token == "" {
+		if strings.IndexFunc(rest, unicode.IsDigit) >= 0 {
+			return fail(ErrInvalidNumber)
+		}
+
+		return fail(ErrEmpty)
+	}
+
+	plain, err := locale.normalize(token)
+	if err != nil {
+		return fail(err)
+	}
+
+	if !isSuffix(rest) {
+		return fail(ErrUnexpectedText)
+	}
+
+	value, err := decimal.NewFromString(plain)
+	if err != nil {
+		return fail(ErrInvalidNumber)
+	}
+
+	if negative {
+		value = value.Neg()
+	}
+
+	return value, nil
+}
+
+// ParseLocale parses a currency amount written in the locale with the given tag
+func ParseLocale(text string, tag string) (decimal.Decimal, error) {
+	locale, err := LookupLocale(tag)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	return Parse(text, locale)
+}
+
+// normalize turns a number token into a plain "1234.56" string, validating its separators
+func (locale Locale) normalize(token string) (string, error) {
+	integerPart, fractionPart, hasDecimal := strings.Cut(token, string(locale.Decimal))
+	if strings.ContainsRune(fractionPart, locale.Decimal) {
+		return "", ErrInvalidNumber
+	}
+
+	if strings.IndexFunc(fractionPart, locale.isGroup) >= 0 {
+		return "", ErrInvalidGrouping
+	}
+
+	groups := strings.FieldsFunc(integerPart, locale.isGroup)
+	if len(groups) > 1 && !locale.validGroups(groups) {
+		return "", ErrInvalidGrouping
+	}
+
+	plain := strings.Join(groups, "")
+	if plain == "" {
+		plain = "0"
+	}
+
+	if hasDecimal {
+		if fractionPart == "" {
+			return "", ErrInvalidNumber
+		}
+
+		plain += "." + fractionPart
+	}
+
+	return plain, nil
+}
+
+// validGroups checks that thousands separators split the integer part into valid digit groups
+func (locale Locale) validGroups(groups []string) bool {
+	last := len(groups) - 1
+
+	for i, group := range groups {
+		switch {
+		case i == 0 && locale.IndianGrouping && last > 1:
+			if len(group) < 1 || len(group) > 2 {
+				return false
+			}
+		case i == 0:
+			if len(group) < 1 || len(group) > 3 {
+				return false
+			}
+		case i < last && locale.IndianGrouping:
+			if len(group) != 2 {
+				return false
+			}
+		default:
+			if len(group) != 3 {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// scanNumber splits the text into its leading number token and the rest
+func scanNumber(text string, locale Locale) (string, string) {
+	end := 0
+
+	for i, r := range text {
+		if unicode.IsDigit(r) {
+			end = i + utf8.RuneLen(r)
+
+			continue
+		}
+
+		// Separators only belong to the number when a digit follows
+		if r == locale.Decimal || locale.isGroup(r) {
+			next, _ := utf8.DecodeRuneInString(text[i+utf8.RuneLen(r):])
+			if unicode.IsDigit(next) {
+				continue
+			}
+		}
+
+		break
+	}
+
+	// Leading separators are not part of a number
+	token := text[:end]
+	if token != "" {
+		first, _ := utf8.DecodeRuneInString(token)
+		if !unicode.IsDigit(first) && first != locale.Decimal {
+			return "", text
+		}
+	}
+
+	return token, text[end:]
+}
+
+// isSuffix reports whether the text following a number is a unit or currency suffix
+func isSuffix(text string) bool {
+	text = strings.TrimSpace(text)
+
+	// Indonesian style "Rp 18.500,-" or "Rp 18.500.-"
+	text = strings.TrimPrefix(text, ",-")
+	text = strings.TrimPrefix(text, ".-")
+	text = strings.TrimSpace(text)
+
+	if text == "" {
+		return true
+	}
+
+	first, _ := utf8.DecodeRuneInString(text)
+
+	return first == '/' || unicode.IsLetter(first) || unicode.Is(unicode.Sc, first)
+}
+
+func normalizeSpaces(text string) string {
+	return strings.Join(strings.Fields(spaces.Replace(text)), " ")
+}
+
+func trimSign(text string) (string, bool) {
+	for _, sign := range []string{"-", "−"} {
+		if strings.HasPrefix(text, sign) {
+			return strings.TrimSpace(strings.TrimPrefix(text, sign)), true
+		}
+	}
+
+	return text, false
+}
+
+func trimCurrency(text string) string {
+	for _, currency := range currencies {
+		if len(text) < len(currency) || !strings.EqualFold(text[:len(currency)], currency) {
+			continue
+		}
+
+		rest := text[len(currency):]
+
+		// Avoid eating the start of a word, e.g. "Rpx"
+		next, _ := utf8.DecodeRuneInString(rest)
+		if unicode.IsLetter(next) {
+			continue
+		}
+
+		// Abbreviations may end with a dot, e.g. "Rp."
+		last, _ := utf8.DecodeLastRuneInString(currency)
+		if unicode.IsLetter(last) {
+			rest = strings.TrimPrefix(rest, ".")
+		}
+
+		return strings.TrimSpace(rest)
+	}
+
+	return text
+}
@@ -0,0 +1,109 @@
+package number
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		locale string
+		text   string
+		want   string
+	}{
+		// Thousands and decimal separators of each locale
+		{name: "id thousands", locale: "id-ID", text: "1.234.567", want: "1234567"},
+		{name: "id decimal", locale: "id-ID", text: "1.234,56", want: "1234.56"},
+		{name: "en thousands", locale: "en-US", text: "1,234,567", want: "1234567"},
+		{name: "en decimal", locale: "en-US", text: "1,234.56", want: "1234.56"},
+		{name: "de decimal", locale: "de-DE", text: "1.234,56", want: "1234.56"},
+		{name: "fr space groups", locale: "fr-FR", text: "1 234,56", want: "1234.56"},
+		{name: "fr non-breaking space groups", locale: "fr-FR", text: "1 234 567", want: "1234567"},
+		{name: "ch apostrophe groups", locale: "de-CH", text: "1'234.50", want: "1234.5"},
+		{name: "ch right quote groups", locale: "de-CH", text: "1’234", want: "1234"},
+		{name: "in lakh grouping", locale: "en-IN", text: "1,00,000", want: "100000"},
+		{name: "in crore grouping", locale: "en-IN", text: "1,23,45,678.90", want: "12345678.9"},
+
+		// The same text means another number in another locale
+		{name: "ambiguous dot in id", locale: "id-ID", text: "1.234", want: "1234"},
+		{name: "ambiguous dot in en", locale: "en-US", text: "1.234", want: "1.234"},
+		{name: "ambiguous comma in id", locale: "id-ID", text: "1,5", want: "1.5"},
+		{name: "ambiguous comma in de", locale: "de-DE", text: "1,5", want: "1.5"},
+
+		// Currencies, units and signs around the number
+		{name: "rupiah", locale: "id-ID", text: "Rp 18.500", want: "18500"},
+		{name: "rupiah with dot", locale: "id-ID", text: "Rp. 18.500,-", want: "18500"},
+		{name: "rupiah without space", locale: "id-ID", text: "Rp18.500", want: "18500"},
+		{name: "idr code", locale: "id-ID", text: "IDR 1.250.000", want: "1250000"},
+		{name: "per unit", locale: "id-ID", text: "Rp 18.500 / 0,01 gr", want: "18500"},
+		{name: "currency suffix", locale: "id-ID", text: "1.250.000 IDR", want: "1250000"},
+		{name: "dollar", locale: "en-US", text: "$1,234.50", want: "1234.5"},
+		{name: "negative", locale: "en-US", text: "-$12.50", want: "-12.5"},
+		{name: "negative after currency", locale: "en-US", text: "$-12.50", want: "-12.5"},
+		{name: "leading decimal", locale: "en-US", text: ".5", want: "0.5"},
+		{name: "surrounding spaces", locale: "id-ID", text: "  18.500  ", want: "18500"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseLocale(test.text, test.locale)
+			if err != nil {
+				t.Fatalf("ParseLocale(%q, %q) failed: %v", test.text, test.locale, err)
+			}
+
+			if got.String() != test.want {
+				t.Errorf("ParseLocale(%q, %q) = %s, want %s", test.text, test.locale, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		locale string
+		text   string
+		want   error
+	}{
+		{name: "empty", locale: "id-ID", text: "", want: ErrEmpty},
+		{name: "no digits", locale: "id-ID", text: "Rp -", want: ErrEmpty},
+		{name: "comma groups in id", locale: "id-ID", text: "1,234,567", want: ErrInvalidNumber},
+		{name: "dot groups in en", locale: "en-US", text: "1.234.567", want: ErrInvalidNumber},
+		{name: "short group", locale: "id-ID", text: "1.23.456", want: ErrInvalidGrouping},
+		{name: "long first group", locale: "en-US", text: "1234,567", want: ErrInvalidGrouping},
+		{name: "separator in fraction", locale: "en-US", text: "1.234,5", want: ErrInvalidGrouping},
+		{name: "lakh grouping outside india", locale: "en-US", text: "1,00,000", want: ErrInvalidGrouping},
+		{name: "text after number", locale: "id-ID", text: "18.500 + 200", want: ErrUnexpectedText},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseLocale(test.text, test.locale)
+			if !errors.Is(err, test.want) {
+				t.Fatalf("ParseLocale(%q, %q) = %s, %v, want %v", test.text, test.locale, got, err, test.want)
+			}
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) || parseErr.Input != test.text {
+				t.Errorf("ParseLocale(%q, %q) error %v does not name its input", test.text, test.locale, err)
+			}
+		})
+	}
+}
+
+func TestLookupLocale(t *testing.T) {
+	for _, tag := range []string{"id-ID", "ID-id", "id_ID", " en-US "} {
+		if _, err := LookupLocale(tag); err != nil {
+			t.Errorf("LookupLocale(%q) failed: %v", tag, err)
+		}
+	}
+
+	if _, err := LookupLocale("xx-XX"); !errors.Is(err, ErrUnknownLocale) {
+		t.Errorf("LookupLocale(%q) = %v, want %v", "xx-XX", err, ErrUnknownLocale)
+	}
+
+	if _, err := ParseLocale("1", "xx-XX"); !errors.Is(err, ErrUnknownLocale) {
+		t.Errorf("ParseLocale with an unknown locale = %v, want %v", err, ErrUnknownLocale)
+	}
+}